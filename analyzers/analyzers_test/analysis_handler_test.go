@@ -0,0 +1,69 @@
+package analyzers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	myhttp "github.com/janithT/webpage-analyzer/handler/http"
+	"github.com/janithT/webpage-analyzer/store"
+)
+
+func TestGetAnalysisHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/analyses/:id", myhttp.GetAnalysisHandler)
+
+	analysisStore := store.NewAnalysisStore(10)
+	store.SetSharedAnalysisStore(analysisStore)
+	defer store.SetSharedAnalysisStore(nil)
+
+	analysis := analysisStore.Save("https://example.com", map[string]interface{}{
+		"urls": map[string]interface{}{
+			"total_count": len(queryLinks),
+			"links":       queryLinks,
+		},
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/analyses/"+analysis.ID+"?status=broken&limit=1", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data struct {
+			Urls struct {
+				TotalCount int                      `json:"total_count"`
+				Links      []map[string]interface{} `json:"links"`
+				Pagination analyzers.Pagination     `json:"pagination"`
+			} `json:"urls"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body.Data.Urls.TotalCount != len(queryLinks) {
+		t.Errorf("expected the total count to stay %d, got %d", len(queryLinks), body.Data.Urls.TotalCount)
+	}
+	if len(body.Data.Urls.Links) != 1 || body.Data.Urls.Pagination.MatchingCount != 3 || body.Data.Urls.Pagination.NextCursor == "" {
+		t.Errorf("expected 1 of 3 broken links with a next cursor, got %+v", body.Data.Urls)
+	}
+
+	// The stored links are not changed by the query
+	stored := analysis.Data["urls"].(map[string]interface{})["links"].([]analyzers.LinkProperty)
+	if len(stored) != len(queryLinks) {
+		t.Errorf("expected the stored links to be kept, got %d", len(stored))
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/analyses/unknown", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown id, got %d", w.Code)
+	}
+}
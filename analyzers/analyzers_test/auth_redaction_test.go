@@ -0,0 +1,72 @@
+package analyzers_test
+
+import (
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	myhttp "github.com/janithT/webpage-analyzer/handler/http"
+)
+
+// test the secrets of the auth step never reach the response, also when json would escape them
+func TestAnalyzeHandlerRedactsAuthSecrets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/analyze", myhttp.AnalyzeHandler)
+
+	const username = "tester@example.com"
+	const password = "pa&ss<word>"
+
+	// The proxy stands in for the site, the handler only accepts public host names
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "logged-in", Path: "/"})
+		case "/profile":
+			io.WriteString(w, `<!DOCTYPE html><html><title>Profile</title><body><h1>`+username+`</h1><img src="/me.png" alt="Password `+html.EscapeString(password)+`"></body></html>`)
+		case "/broken":
+			http.Redirect(w, r, "/closed?user="+username, http.StatusFound)
+		case "/closed":
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}
+	}))
+	defer site.Close()
+
+	fetcher.SetAuthSteps([]fetcher.AuthStep{{
+		Name:          "site",
+		LoginURL:      "http://monitored.example.com/login",
+		UsernameField: "email",
+		PasswordField: "password",
+		Username:      username,
+		Password:      password,
+		SessionCookie: "session",
+	}})
+	defer fetcher.SetAuthSteps(nil)
+
+	analyze := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/analyze?auth=site&url="+url.QueryEscape("http://monitored.example.com"+path)+"&proxy="+url.QueryEscape(site.URL), nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := analyze("/profile")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "[REDACTED]") {
+		t.Fatalf("expected a redacted analysis, got %d %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "ss\\u003cword") || strings.Contains(w.Body.String(), username) {
+		t.Errorf("expected the secrets to be redacted, got %s", w.Body.String())
+	}
+
+	w = analyze("/broken")
+	if w.Code == http.StatusOK || strings.Contains(w.Body.String(), username) {
+		t.Errorf("expected a fetch error without the username, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+package analyzers_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	myhttp "github.com/janithT/webpage-analyzer/handler/http"
+	"github.com/janithT/webpage-analyzer/store"
+)
+
+func TestAnalyzeHandlerReusesUnchangedPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/analyze", myhttp.AnalyzeHandler)
+
+	store.SetSharedAnalysisStore(store.NewAnalysisStore(10))
+	defer store.SetSharedAnalysisStore(nil)
+
+	// The proxy stands in for the site, the handler only accepts public host names
+	fetches := 0
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		// Soft 404 probes ask for random paths
+		if r.URL.Path == "/" {
+			fetches++
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<!DOCTYPE html><html><title>Monitored</title><body><h1>Status</h1></body></html>")
+	}))
+	defer site.Close()
+
+	analyze := func(extra string) (int, string, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/analyze?url="+url.QueryEscape("http://monitored.example.com/")+"&proxy="+url.QueryEscape(site.URL)+extra, nil)
+		router.ServeHTTP(w, req)
+		var body struct {
+			Message string                 `json:"message"`
+			Data    map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		return w.Code, body.Message, body.Data
+	}
+
+	code, _, first := analyze("")
+	if code != http.StatusOK || first["unchanged"] != false || first["title"] != "Monitored" {
+		t.Fatalf("expected a fresh analysis, got %d %v", code, first)
+	}
+
+	code, message, second := analyze("")
+	if code != http.StatusOK || second["unchanged"] != true || message != "Page unchanged since the previous analysis, its results are replayed" {
+		t.Errorf("expected the page to be unchanged, got %d %q %v", code, message, second["unchanged"])
+	}
+	if second["title"] != "Monitored" || second["analysisId"] != first["analysisId"] || second["analyzedAt"] == nil {
+		t.Errorf("expected the previous results to be reused, got %v", second)
+	}
+	if fetches != 1 {
+		t.Errorf("expected one full download, got %d", fetches)
+	}
+
+	// Other options and noCache analyze again
+	if _, _, data := analyze("&userAgent=mobile"); data["unchanged"] != false {
+		t.Errorf("expected another user agent to be analyzed again")
+	}
+	if _, _, data := analyze("&noCache=true"); data["unchanged"] != false {
+		t.Errorf("expected noCache to analyze again")
+	}
+	if fetches != 3 {
+		t.Errorf("expected three full downloads, got %d", fetches)
+	}
+}
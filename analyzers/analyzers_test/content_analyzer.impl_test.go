@@ -0,0 +1,61 @@
+package analyzers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+func TestContentAnalyzer(t *testing.T) {
+	html := `<html><head><title>Ignored title</title><style>.x{}</style></head><body>
+		<nav>Home About Contact</nav>
+		<h1>Coffee brewing guide</h1>
+		<p>The cat sat on the mat. Coffee brewing is simple! Good coffee brewing needs fresh beans?</p>
+		<script>var ignored = "script text";</script>
+		<footer>Copyright footer text</footer>
+	</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+
+	result := analyzers.ContentAnalyzer().Analyze(doc, html)
+	if result.Key != "content" {
+		t.Errorf("expected key 'content', got %q", result.Key)
+	}
+
+	report := result.Value.(models.ContentReport)
+	if report.WordCount != 19 {
+		t.Errorf("expected 19 words, got %d", report.WordCount)
+	}
+	if report.SentenceCount != 4 {
+		t.Errorf("expected 4 sentences, got %d", report.SentenceCount)
+	}
+	if report.ReadingTimeSeconds != 5 {
+		t.Errorf("expected 5 seconds reading time, got %d", report.ReadingTimeSeconds)
+	}
+	if report.FleschReadingEase <= 50 || report.FleschReadingEase > 121 {
+		t.Errorf("expected easy text, got flesch %v", report.FleschReadingEase)
+	}
+	if len(report.Keywords) == 0 || report.Keywords[0].Term != "brewing" || report.Keywords[0].Count != 3 {
+		t.Errorf("expected top keyword 'brewing', got %+v", report.Keywords)
+	}
+	if len(report.Bigrams) == 0 || report.Bigrams[0].Term != "coffee brewing" || report.Bigrams[0].Count != 3 {
+		t.Errorf("expected top bigram 'coffee brewing', got %+v", report.Bigrams)
+	}
+	if report.TextToHTMLRatio <= 0 || report.TextToHTMLRatio >= 100 {
+		t.Errorf("unexpected text to html ratio %v", report.TextToHTMLRatio)
+	}
+}
+
+func TestContentAnalyzer_Empty(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader("<html><body><script>x()</script></body></html>"))
+	report := analyzers.ContentAnalyzer().Analyze(doc, "").Value.(models.ContentReport)
+	if report.WordCount != 0 || report.SentenceCount != 0 {
+		t.Errorf("expected empty report, got %+v", report)
+	}
+}
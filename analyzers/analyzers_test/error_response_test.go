@@ -0,0 +1,82 @@
+package analyzers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	myhttp "github.com/janithT/webpage-analyzer/handler/http"
+	"github.com/janithT/webpage-analyzer/handler/middleware"
+	"github.com/janithT/webpage-analyzer/responses"
+)
+
+func TestErrorResponseCodeAndRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.GET("/analyze", myhttp.AnalyzeHandler)
+
+	var body responses.BaseResponse
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/analyze?url=not-a-url", nil)
+	req.Header.Set(responses.RequestIDHeader, "client-id-1")
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body.Code != responses.CodeInvalidURL || body.Details["url"] != "not-a-url" {
+		t.Errorf("expected INVALID_URL with the url in the details, got %+v", body)
+	}
+	if body.RequestID != "client-id-1" || w.Header().Get(responses.RequestIDHeader) != "client-id-1" {
+		t.Errorf("expected the client request id to be kept, got %q and header %q", body.RequestID, w.Header().Get(responses.RequestIDHeader))
+	}
+
+	// Invalid parameters have their own code and a generated id
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/analyze?url=https://example.com&noCache=maybe", nil)
+	req.Header.Set(responses.RequestIDHeader, "bad id\n")
+	router.ServeHTTP(w, req)
+	body = responses.BaseResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if body.Code != responses.CodeInvalidParameter {
+		t.Errorf("expected INVALID_PARAMETER, got %q", body.Code)
+	}
+	if len(body.RequestID) != 32 || body.RequestID != w.Header().Get(responses.RequestIDHeader) {
+		t.Errorf("expected a generated request id, got %q", body.RequestID)
+	}
+
+	// Credentials may not be passed in the query string
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/analyze?url=https://example.com&header=Authorization:+Bearer+x", nil)
+	router.ServeHTTP(w, req)
+	body = responses.BaseResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if w.Code != http.StatusBadRequest || body.Code != responses.CodeInvalidParameter {
+		t.Errorf("expected an Authorization header parameter to be rejected, got %d %q", w.Code, body.Code)
+	}
+
+	// Auth profiles only analyze their own hosts
+	fetcher.SetAuthSteps([]fetcher.AuthStep{{Name: "staging", LoginURL: "https://staging.example.com/login"}})
+	defer fetcher.SetAuthSteps(nil)
+
+	for _, query := range []string{"&password=hunter2", "&auth=unknown", "&auth=staging", "&insecureSkipVerify=true",
+		"&cookie=session%3Dabc", "&proxy=http%3A%2F%2Fuser%3Apass%40proxy.example.com%3A3128"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/analyze?url=https://example.com"+query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected %s to be rejected, got %d", query, w.Code)
+		}
+	}
+}
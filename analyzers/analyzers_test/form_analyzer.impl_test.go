@@ -0,0 +1,138 @@
+package analyzers_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+func newTestDocument(t *testing.T, html string) *goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+	doc.Url, _ = url.Parse("https://example.com/account")
+	return doc
+}
+
+func TestFormAnalyzer_Classification(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     string
+		expected string
+	}{
+		{
+			name: "login",
+			form: `<form action="/session" method="post">
+				<input type="email" name="email" autocomplete="username">
+				<input type="password" name="password" autocomplete="current-password">
+				<button>Sign in</button></form>`,
+			expected: analyzers.FormLogin,
+		},
+		{
+			name: "signup",
+			form: `<form action="/users" method="post">
+				<input name="first_name"><input type="email" name="email">
+				<input type="password" name="password" autocomplete="new-password">
+				<input type="password" name="password_confirmation">
+				<button type="submit">Create account</button></form>`,
+			expected: analyzers.FormSignup,
+		},
+		{
+			name: "password change",
+			form: `<form action="/settings/password" method="post">
+				<input type="password" name="old" autocomplete="current-password">
+				<input type="password" name="new" autocomplete="new-password">
+				<button>Update password</button></form>`,
+			expected: analyzers.FormPasswordReset,
+		},
+		{
+			name:     "search",
+			form:     `<form action="/search" role="search"><input type="search" name="q"><button>Search</button></form>`,
+			expected: analyzers.FormSearch,
+		},
+		{
+			name:     "newsletter",
+			form:     `<form action="https://example.us1.list-manage.com/subscribe/post" method="post"><input type="email" name="EMAIL"><input type="submit" value="Subscribe"></form>`,
+			expected: analyzers.FormNewsletter,
+		},
+		{
+			name: "payment",
+			form: `<form action="/checkout" method="post">
+				<input name="card_number" autocomplete="cc-number"><input name="cvc" autocomplete="cc-csc">
+				<button>Pay now</button></form>`,
+			expected: analyzers.FormPayment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newTestDocument(t, "<html><body>"+tt.form+"</body></html>")
+			report := analyzers.FormAnalyzer().Analyze(doc, "").Value.(models.FormsReport)
+			if report.FormCount != 1 {
+				t.Fatalf("expected 1 form, got %d", report.FormCount)
+			}
+			form := report.Forms[0]
+			if form.Classification != tt.expected {
+				t.Errorf("expected %q, got %q (signals %v)", tt.expected, form.Classification, form.Signals)
+			}
+			if form.Confidence <= 0 || form.Confidence > 100 {
+				t.Errorf("unexpected confidence %d", form.Confidence)
+			}
+		})
+	}
+}
+
+func TestLoginFormAnalyzer(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected bool
+	}{
+		{
+			name:     "login form",
+			html:     `<form><input name="user"><input type="password" name="pass"><button>Log in</button></form>`,
+			expected: true,
+		},
+		{
+			name:     "signup form only",
+			html:     `<form><input type="email" name="email"><input type="password" autocomplete="new-password"><input type="password"><button>Sign up</button></form>`,
+			expected: false,
+		},
+		{
+			name:     "hidden template",
+			html:     `<template><form><input name="user"><input type="password"><button>Log in</button></form></template>`,
+			expected: false,
+		},
+		{
+			name:     "password field outside a form",
+			html:     `<div id="app"><input type="email"><input type="password"><button>Sign in</button></div>`,
+			expected: true,
+		},
+		{
+			name:     "hidden password field outside a form",
+			html:     `<div style="display:none"><input type="password"></div>`,
+			expected: false,
+		},
+		{
+			name:     "sso only",
+			html:     `<a href="https://accounts.google.com/o/oauth2/auth?client_id=1">Continue with Google</a>`,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := newTestDocument(t, "<html><body>"+tt.html+"</body></html>")
+			result := analyzers.LoginFormAnalyzer().Analyze(doc, tt.html)
+			if result.Key != "hasLoginForm" || result.Value != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result.Value)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package analyzers_test
+
+import (
+	"testing"
+
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+func TestFormSecurityAnalyzer(t *testing.T) {
+	html := `<html><body>
+		<form id="login" action="http://example.com/login" method="get">
+			<input name="user"><input type="password" name="pass" autocomplete="off">
+			<button><img src="/go.png"></button>
+		</form>
+		<form id="remote" action="https://auth.other.com/login" method="post">
+			<input type="hidden" name="csrf_token" value="abc">
+			<input name="user"><input type="password" name="pass">
+			<input type="submit">
+		</form>
+		<form id="explicit-port" action="https://example.com:443/login" method="post">
+			<input type="hidden" name="csrf_token" value="abc">
+			<input name="user"><input type="password" name="pass">
+			<input type="submit">
+		</form>
+		<form id="contact" method="post">
+			<input type="email"><textarea name="message"></textarea>
+			<button>Send</button>
+		</form>
+	</body></html>`
+
+	doc := newTestDocument(t, html)
+	result := analyzers.FormSecurityAnalyzer().Analyze(doc, html)
+	if result.Key != "formSecurity" {
+		t.Errorf("expected key 'formSecurity', got %q", result.Key)
+	}
+
+	report := result.Value.(models.FormSecurityReport)
+	findings := make(map[string]models.Finding)
+	for _, f := range report.Findings {
+		findings[f.RuleID] = f
+	}
+
+	expected := map[string]int{
+		"form-password-insecure-action":  1,
+		"form-password-get":              1,
+		"form-password-cross-origin":     2,
+		"form-missing-csrf-token":        1,
+		"form-password-autocomplete-off": 1,
+		"form-button-no-text":            1,
+		"form-input-missing-name":        1,
+	}
+	for rule, count := range expected {
+		if findings[rule].Count != count {
+			t.Errorf("expected %d %s findings, got %+v", count, rule, findings[rule])
+		}
+	}
+	if len(findings) != len(expected) {
+		t.Errorf("unexpected findings %+v", report.Findings)
+	}
+	if report.SeverityCounts[models.SeverityError] != 2 {
+		t.Errorf("expected 2 errors, got %v", report.SeverityCounts)
+	}
+}
@@ -0,0 +1,83 @@
+package analyzers_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+func TestImageAnalyzer(t *testing.T) {
+	// Served content types and sizes per path
+	served := map[string]struct {
+		contentType string
+		size        int64
+	}{
+		"/hero":       {"image/jpeg", 300 * 1024},
+		"/logo.svg":   {"image/svg+xml", 2 * 1024},
+		"/small.png":  {"image/png", 40 * 1024},
+		"/large.png":  {"image/png", 90 * 1024},
+		"/photo.avif": {"image/avif", 30 * 1024},
+		"/photo.jpg":  {"image/jpeg", 80 * 1024},
+		"/bg.webp":    {"image/webp", 20 * 1024},
+	}
+	checker := fetcher.NewLinkCheckerWithClient(&http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			file := served[req.URL.Path]
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				ContentLength: file.size,
+				Header:        http.Header{"Content-Type": {file.contentType}},
+				Body:          io.NopCloser(strings.NewReader("")),
+				Request:       req,
+			}
+		}),
+	})
+
+	html := `<html><body>
+		<img src="/hero" alt="Hero" width="1200" height="600">
+		<img src="/logo.svg" alt="">
+		<img src="/small.png" srcset="/small.png 1x, /large.png 2x" alt="Responsive">
+		<picture>
+			<source srcset="/photo.avif" type="image/avif">
+			<img src="/photo.jpg" alt="Photo">
+		</picture>
+		<div style="background-image: url('/bg.webp')"></div>
+		<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+	</body></html>`
+
+	doc := newTestDocument(t, html)
+	result := analyzers.ImageAnalyzer(checker).Analyze(doc, html)
+	if result.Key != "images" {
+		t.Errorf("expected key 'images', got %q", result.Key)
+	}
+
+	report := result.Value.(models.ImageReport)
+	if report.ImageCount != 8 {
+		t.Errorf("expected 8 images, got %d: %+v", report.ImageCount, report.Images)
+	}
+	if report.FormatCounts["jpeg"] != 2 || report.FormatCounts["svg"] != 1 || report.FormatCounts["webp"] != 1 {
+		t.Errorf("unexpected formats %v", report.FormatCounts)
+	}
+
+	findings := make(map[string]models.Finding)
+	for _, f := range report.Findings {
+		findings[f.RuleID] = f
+	}
+	if f := findings["img-oversized"]; f.Count != 1 {
+		t.Errorf("expected one oversized image, got %+v", f)
+	}
+	if f := findings["img-missing-srcset"]; f.Count != 1 || !strings.HasSuffix(f.Items[0], "/hero") {
+		t.Errorf("expected hero without srcset, got %+v", f)
+	}
+	if f := findings["img-legacy-format"]; f.Count != 4 {
+		t.Errorf("expected 4 legacy format images, got %+v", f)
+	}
+	if _, ok := findings["img-missing-alt"]; ok {
+		t.Errorf("did not expect missing alt for data uri images")
+	}
+}
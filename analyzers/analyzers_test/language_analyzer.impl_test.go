@@ -0,0 +1,108 @@
+package analyzers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+func TestLanguageAnalyzer_Detection(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"english", "We are happy to announce that our new store opens next week. Visit us to find great prices on all of the latest products.", "en"},
+		{"german", "Wir freuen uns, Ihnen mitteilen zu können, dass unser neues Geschäft nächste Woche eröffnet. Besuchen Sie uns für günstige Preise.", "de"},
+		{"french", "Nous sommes heureux d'annoncer que notre nouveau magasin ouvre la semaine prochaine. Venez découvrir nos prix sur tous les produits.", "fr"},
+		{"spanish", "Nos complace anunciar que nuestra nueva tienda abre la próxima semana. Visítenos para encontrar excelentes precios en todos los productos.", "es"},
+		{"dutch", "We zijn blij te kunnen melden dat onze nieuwe winkel volgende week opent. Kom langs voor de beste prijzen op alle nieuwe producten.", "nl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := `<html lang="` + tt.expected + `"><body><p>` + tt.text + `</p></body></html>`
+			doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+			report := analyzers.LanguageAnalyzer("").Analyze(doc, html).Value.(models.LanguageReport)
+			if report.DetectedLang != tt.expected {
+				t.Errorf("expected %q, got %q (confidence %v)", tt.expected, report.DetectedLang, report.Confidence)
+			}
+			if len(report.Findings) != 0 {
+				t.Errorf("expected no findings, got %+v", report.Findings)
+			}
+		})
+	}
+}
+
+func TestLanguageAnalyzer_Mismatch(t *testing.T) {
+	html := `<html lang="en"><body>
+		<section id="main"><p>Wir freuen uns, Ihnen mitteilen zu können, dass unser neues Geschäft nächste Woche eröffnet. Besuchen Sie uns für günstige Preise auf alle Produkte.</p></section>
+		<section id="intl" lang="de"><p>Our new store opens next week and we would be happy to see you there. Visit us to find great prices on all of the products.</p></section>
+	</body></html>`
+
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	result := analyzers.LanguageAnalyzer("en-US").Analyze(doc, html)
+	if result.Key != "language" {
+		t.Errorf("expected key 'language', got %q", result.Key)
+	}
+
+	report := result.Value.(models.LanguageReport)
+	rules := make(map[string]bool)
+	for _, f := range report.Findings {
+		rules[f.RuleID] = true
+	}
+	if !rules["lang-section-mismatch"] {
+		t.Errorf("expected section mismatch, got %+v", report.Findings)
+	}
+	if len(report.Sections) != 2 || report.Sections[0].DetectedLang != "de" || report.Sections[1].DetectedLang != "en" {
+		t.Errorf("unexpected sections %+v", report.Sections)
+	}
+	if report.Distribution["de"] == 0 || report.Distribution["en"] == 0 {
+		t.Errorf("expected both languages in distribution, got %v", report.Distribution)
+	}
+}
+
+func TestLanguageAnalyzer_HeaderMismatch(t *testing.T) {
+	html := `<html><body><p>Wir freuen uns, Ihnen mitteilen zu können, dass unser neues Geschäft nächste Woche eröffnet.</p></body></html>`
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	report := analyzers.LanguageAnalyzer("en").Analyze(doc, html).Value.(models.LanguageReport)
+	rules := make(map[string]bool)
+	for _, f := range report.Findings {
+		rules[f.RuleID] = true
+	}
+	if !rules["lang-header-mismatch"] || rules["lang-missing-declaration"] {
+		t.Errorf("expected header mismatch only, got %+v", report.Findings)
+	}
+}
+
+func TestLanguageAnalyzer_MissingDeclaration(t *testing.T) {
+	html := `<html><body><p>We are happy to announce that our new store opens next week in the city centre.</p></body></html>`
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	report := analyzers.LanguageAnalyzer("").Analyze(doc, html).Value.(models.LanguageReport)
+	rules := make(map[string]bool)
+	for _, f := range report.Findings {
+		rules[f.RuleID] = true
+	}
+	if !rules["lang-missing-declaration"] {
+		t.Errorf("expected a missing declaration finding, got %+v", report.Findings)
+	}
+}
+
+// test the detection is reported as a capped hint and similar languages are not reported as mismatches
+func TestLanguageAnalyzer_Hint(t *testing.T) {
+	html := `<html lang="pt"><body><p>Nos complace anunciar que nuestra nueva tienda abre la próxima semana. Visítenos para encontrar excelentes precios en todos los productos.</p></body></html>`
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	report := analyzers.LanguageAnalyzer("").Analyze(doc, html).Value.(models.LanguageReport)
+
+	if !report.Hint || report.Confidence > 0.5 {
+		t.Errorf("expected a hint with at most 0.5 confidence, got %v %v", report.Hint, report.Confidence)
+	}
+	for _, f := range report.Findings {
+		if f.RuleID == "lang-html-mismatch" {
+			t.Errorf("expected no mismatch between similar languages, got %+v", f)
+		}
+	}
+}
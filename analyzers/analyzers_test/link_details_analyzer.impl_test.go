@@ -0,0 +1,365 @@
+package analyzers_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+// linkResult runs the link analyzer on html served as the index page of a test server
+func linkResult(t *testing.T, ts *httptest.Server, html string) map[string]interface{} {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+	doc.Url, _ = url.Parse(ts.URL + "/")
+
+	result := analyzers.LinkAnalyzer(fetcher.NewLinkChecker(5*time.Second), analyzers.LinkClassification{}, nil).Analyze(doc, html)
+	if result.Key != "urls" {
+		t.Fatalf("expected key 'urls', got %q", result.Key)
+	}
+	return result.Value.(map[string]interface{})
+}
+
+// linksByUrl indexes the link results by url relative to the test server
+func linksByUrl(ts *httptest.Server, value map[string]interface{}) map[string]analyzers.LinkProperty {
+	links := make(map[string]analyzers.LinkProperty)
+	for _, link := range value["links"].([]analyzers.LinkProperty) {
+		links[strings.TrimPrefix(link.Url, ts.URL)] = link
+	}
+	return links
+}
+
+func TestLinkAnalyzer_Anchors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/docs" {
+			io.WriteString(w, `<html><body><h2 id="install">Install</h2><a name="usage"></a></body></html>`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	html := `<html><body>
+		<h2 id="intro">Intro</h2>
+		<a href="#intro">Intro</a>
+		<a href="#missing">Missing</a>
+		<a href="/docs#install">Install</a>
+		<a href="/docs#usage">Usage</a>
+		<a href="/docs#gone">Gone</a>
+		<a href="/app#!/route">Route</a>
+	</body></html>`
+
+	value := linkResult(t, ts, html)
+	links := linksByUrl(ts, value)
+
+	expected := map[string]string{
+		"/#intro":       analyzers.AnchorOk,
+		"/#missing":     analyzers.AnchorMissing,
+		"/docs#install": analyzers.AnchorOk,
+		"/docs#usage":   analyzers.AnchorOk,
+		"/docs#gone":    analyzers.AnchorMissing,
+		"/app#!/route":  analyzers.AnchorUnchecked,
+	}
+	for u, status := range expected {
+		if links[u].AnchorStatus != status {
+			t.Errorf("expected %s anchor to be %q, got %q", u, status, links[u].AnchorStatus)
+		}
+	}
+	if value["broken_anchor_count"] != 2 {
+		t.Errorf("expected 2 broken anchors, got %v", value["broken_anchor_count"])
+	}
+	if links["/docs#gone"].StatusCode != http.StatusOK {
+		t.Errorf("broken anchor should not break the url, got %d", links["/docs#gone"].StatusCode)
+	}
+}
+
+func TestLinkAnalyzer_ExtractsAllSources(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	html := `<html><head>
+		<base href="/static/">
+		<meta http-equiv="refresh" content="30; url=/next">
+		<style>body { background: url("bg.png") }</style>
+	</head><body>
+		<a href="page.html">One</a>
+		<a href="page.html">Two</a>
+		<iframe src="frame.html"></iframe>
+		<video poster="poster.jpg"><source src="clip.mp4"></video>
+		<picture><source srcset="small.webp 1x, large.webp 2x"></picture>
+		<audio src="sound.mp3"></audio>
+		<form action="/submit"></form>
+		<map><area href="area.html"></map>
+		<object data="movie.swf"></object>
+		<div style="background-image: url('page.html')"></div>
+		<a href="mailto:info@example.com">Mail</a>
+	</body></html>`
+
+	value := linkResult(t, ts, html)
+	links := linksByUrl(ts, value)
+
+	expected := map[string]string{
+		"/static/":           "base[href]",
+		"/next":              "meta[content]",
+		"/static/bg.png":     "style[url()]",
+		"/static/frame.html": "iframe[src]",
+		"/static/poster.jpg": "video[poster]",
+		"/static/clip.mp4":   "source[src]",
+		"/static/small.webp": "source[srcset]",
+		"/static/large.webp": "source[srcset]",
+		"/static/sound.mp3":  "audio[src]",
+		"/submit":            "form[action]",
+		"/static/area.html":  "area[href]",
+		"/static/movie.swf":  "object[data]",
+	}
+	for u, source := range expected {
+		link, ok := links[u]
+		if !ok {
+			t.Errorf("expected link %s to be extracted", u)
+			continue
+		}
+		if len(link.Sources) != 1 || link.Sources[0] != source {
+			t.Errorf("expected %s to come from %s, got %v", u, source, link.Sources)
+		}
+	}
+
+	page := links["/static/page.html"]
+	if page.Occurrences != 3 {
+		t.Errorf("expected page.html to occur 3 times, got %d", page.Occurrences)
+	}
+	if len(page.Sources) != 2 || page.Sources[0] != "a[href]" || page.Sources[1] != "div[style]" {
+		t.Errorf("expected page.html sources [a[href] div[style]], got %v", page.Sources)
+	}
+	if value["total_count"] != len(expected)+1 {
+		t.Errorf("expected %d links, got %v", len(expected)+1, value["total_count"])
+	}
+}
+
+func TestLinkAnalyzer_Classification(t *testing.T) {
+	html := `<html><body>
+		<a href="https://example.co.uk/about">Same host</a>
+		<a href="https://www.example.co.uk/">www</a>
+		<a href="https://blog.example.co.uk/">Blog</a>
+		<a href="https://other.co.uk/">Other site</a>
+		<a href="https://cdn.example-static.net/app.js">First party cdn</a>
+	</body></html>`
+
+	linkTypes := func(classification analyzers.LinkClassification) (map[string]analyzers.LinkType, map[string]interface{}) {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+		if err != nil {
+			t.Fatalf("failed to create goquery document: %v", err)
+		}
+		doc.Url, _ = url.Parse("https://example.co.uk/")
+
+		value := analyzers.LinkAnalyzer(fakeChecker(0), classification, nil).Analyze(doc, html).Value.(map[string]interface{})
+		types := make(map[string]analyzers.LinkType)
+		for _, link := range value["links"].([]analyzers.LinkProperty) {
+			types[link.Url] = link.Type
+		}
+		return types, value
+	}
+
+	types, value := linkTypes(analyzers.LinkClassification{
+		Scope:             analyzers.LinkScopeRegistrableDomain,
+		FirstPartyDomains: []string{"example-static.net"},
+	})
+	expected := map[string]analyzers.LinkType{
+		"https://example.co.uk/about":           analyzers.Internal,
+		"https://www.example.co.uk/":            analyzers.Internal,
+		"https://blog.example.co.uk/":           analyzers.Subdomain,
+		"https://other.co.uk/":                  analyzers.External,
+		"https://cdn.example-static.net/app.js": analyzers.Internal,
+	}
+	for u, linkType := range expected {
+		if types[u] != linkType {
+			t.Errorf("registrable domain scope: expected %s to be %v, got %v", u, linkType, types[u])
+		}
+	}
+	if value["subdomain_count"] != 1 || value["internal_count"] != 3 || value["external_count"] != 1 {
+		t.Errorf("unexpected counts: %v internal, %v subdomain, %v external",
+			value["internal_count"], value["subdomain_count"], value["external_count"])
+	}
+
+	types, _ = linkTypes(analyzers.LinkClassification{Scope: analyzers.LinkScopeHost})
+	expected = map[string]analyzers.LinkType{
+		"https://example.co.uk/about":           analyzers.Internal,
+		"https://www.example.co.uk/":            analyzers.External,
+		"https://blog.example.co.uk/":           analyzers.External,
+		"https://other.co.uk/":                  analyzers.External,
+		"https://cdn.example-static.net/app.js": analyzers.External,
+	}
+	for u, linkType := range expected {
+		if types[u] != linkType {
+			t.Errorf("host scope: expected %s to be %v, got %v", u, linkType, types[u])
+		}
+	}
+}
+
+func TestParseLinkScope(t *testing.T) {
+	if scope, err := analyzers.ParseLinkScope(""); err != nil || scope != analyzers.LinkScopeRegistrableDomain {
+		t.Errorf("expected empty scope to default to registrable_domain, got %q, %v", scope, err)
+	}
+	if scope, err := analyzers.ParseLinkScope("HOST"); err != nil || scope != analyzers.LinkScopeHost {
+		t.Errorf("expected host scope, got %q, %v", scope, err)
+	}
+	if _, err := analyzers.ParseLinkScope("origin"); err == nil {
+		t.Error("expected an error for an unknown scope")
+	}
+}
+
+func TestLinkAnalyzer_Soft404(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pricing":
+			io.WriteString(w, `<html><head><title>Pricing</title></head><body><h1>Plans</h1><p>Free, team and enterprise plans for every size.</p></body></html>`)
+		case "/broken":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			io.WriteString(w, `<html><head><title>Oops! Page not found</title></head><body><h1>404</h1></body></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	html := `<html><head><title>Home</title></head><body>
+		<h1>Welcome</h1>
+		<a href="/pricing">Pricing</a>
+		<a href="/old-blog">Blog</a>
+		<a href="/broken">Broken</a>
+	</body></html>`
+
+	value := linkResult(t, ts, html)
+	links := linksByUrl(ts, value)
+
+	expected := map[string]string{
+		"/pricing":  analyzers.LinkOk,
+		"/old-blog": analyzers.LinkSoft404,
+		"/broken":   analyzers.LinkBroken,
+	}
+	for u, status := range expected {
+		if links[u].Status != status {
+			t.Errorf("expected %s to be %q, got %q (%v)", u, status, links[u].Status, links[u].Soft404Evidence)
+		}
+	}
+	if len(links["/old-blog"].Soft404Evidence) == 0 {
+		t.Error("expected evidence for the soft 404")
+	}
+	if value["soft_404_count"] != 1 {
+		t.Errorf("expected 1 soft 404, got %v", value["soft_404_count"])
+	}
+	if page := value["page_soft_404"].(fetcher.SoftNotFound); page.Suspected {
+		t.Errorf("expected the page itself not to be suspected, got %v", page.Evidence)
+	}
+}
+
+// fakeResolver answers MX lookups from a map, unknown domains do not exist
+type fakeResolver map[string][]*net.MX
+
+func (r fakeResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func TestLinkAnalyzer_NonHttpLinks(t *testing.T) {
+	html := `<html><body>
+		<a href="mailto:sales@example.com?subject=Hi">Sales</a>
+		<a href="mailto:Info@nomail.example,sales@example.com">Info</a>
+		<a href="mailto:not-an-address">Broken</a>
+		<a href="tel:+1 (555) 123-4567">Call</a>
+		<a href="tel:0044 20 7946 0958;ext=12">Call UK</a>
+		<a href="tel:555-1234">Local</a>
+		<a href="ftp://files.example.com/pub/readme.txt">FTP</a>
+		<a href="javascript:void(0)">Menu</a>
+		<a href="javascript:void(0);">Menu</a>
+		<a href="javascript:openChat()">Chat</a>
+		<img src="data:image/png;base64,iVBORw0KGgo=">
+	</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+	doc.Url, _ = url.Parse("https://example.com/")
+
+	resolver := fakeResolver{
+		"example.com":    {{Host: "mx.example.com.", Pref: 10}},
+		"nomail.example": {{Host: ".", Pref: 0}},
+	}
+	value := analyzers.LinkAnalyzer(fakeChecker(0), analyzers.LinkClassification{}, resolver).Analyze(doc, html).Value.(map[string]interface{})
+	report := value["other_links"].(models.NonHttpLinkReport)
+
+	if value["total_count"] != 0 {
+		t.Errorf("expected no http links, got %v", value["total_count"])
+	}
+	if report.TotalCount != 11 {
+		t.Errorf("expected 11 non http links, got %d", report.TotalCount)
+	}
+	expectedCounts := map[string]int{"mailto": 3, "tel": 3, "ftp": 1, "javascript": 3, "data": 1}
+	for scheme, count := range expectedCounts {
+		if report.SchemeCounts[scheme] != count {
+			t.Errorf("expected %d %s links, got %d", count, scheme, report.SchemeCounts[scheme])
+		}
+	}
+
+	links := make(map[string]models.NonHttpLink)
+	for _, link := range report.Links {
+		links[link.Url] = link
+	}
+	if link := links["mailto:sales@example.com?subject=Hi"]; !link.Valid || link.MxStatus != analyzers.MxOk {
+		t.Errorf("expected a valid mailto with MX, got %+v", link)
+	}
+	if link := links["mailto:Info@nomail.example,sales@example.com"]; link.MxStatus != analyzers.MxMissing || len(link.Addresses) != 2 {
+		t.Errorf("expected the null MX domain to be reported, got %+v", link)
+	}
+	if link := links["mailto:not-an-address"]; link.Valid {
+		t.Errorf("expected an invalid mailto, got %+v", link)
+	}
+	if link := links["tel:+1 (555) 123-4567"]; link.Normalized != "+15551234567" {
+		t.Errorf("expected +15551234567, got %+v", link)
+	}
+	if link := links["tel:0044 20 7946 0958;ext=12"]; link.Normalized != "+442079460958" {
+		t.Errorf("expected +442079460958, got %+v", link)
+	}
+	if link := links["tel:555-1234"]; link.Valid {
+		t.Errorf("expected a number without country code to be invalid, got %+v", link)
+	}
+	if link := links["data:image/png;base64,iVBORw0KGgo="]; link.MediaType != "image/png" || link.Bytes != 8 {
+		t.Errorf("expected an 8 byte png data uri, got %+v", link)
+	}
+	if link := links["javascript:void(0)"]; link.Occurrences != 1 || link.Sources[0] != "a[href]" {
+		t.Errorf("expected one javascript:void(0) link from a[href], got %+v", link)
+	}
+
+	rules := make(map[string]int)
+	for _, finding := range report.Findings {
+		rules[finding.RuleID] = finding.Count
+	}
+	expectedRules := map[string]int{
+		"link-invalid-mailto":  1,
+		"link-mailto-no-mx":    1,
+		"link-invalid-tel":     1,
+		"link-javascript-void": 2,
+	}
+	for rule, count := range expectedRules {
+		if rules[rule] != count {
+			t.Errorf("expected %d %s items, got %d", count, rule, rules[rule])
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package analyzers_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/janithT/webpage-analyzer/analyzers"
+)
+
+var queryLinks = []analyzers.LinkProperty{
+	{Url: "https://example.com/a", Type: analyzers.Internal, StatusCode: 200, Status: analyzers.LinkOk, Latency: 30},
+	{Url: "https://other.com/b", Type: analyzers.External, StatusCode: 404, Status: analyzers.LinkBroken, Latency: 10},
+	{Url: "https://example.com/c", Type: analyzers.Internal, StatusCode: 500, Status: analyzers.LinkBroken, Latency: 50},
+	{Url: "https://other.com/d", Type: analyzers.External, StatusCode: 200, Status: analyzers.LinkOk, Latency: 20},
+	{Url: "https://example.com/e", Type: analyzers.Internal, StatusCode: 0, Status: analyzers.LinkBroken, Latency: 40},
+}
+
+func parseQuery(t *testing.T, raw string) analyzers.LinkQuery {
+	t.Helper()
+	values, _ := url.ParseQuery(raw)
+	query, err := analyzers.ParseLinkQuery(values)
+	if err != nil {
+		t.Fatalf("unexpected error for %q: %v", raw, err)
+	}
+	return query
+}
+
+func linkUrls(links []analyzers.LinkProperty) []string {
+	urls := []string{}
+	for _, link := range links {
+		urls = append(urls, link.Url)
+	}
+	return urls
+}
+
+func TestLinkQuery_FilterAndSort(t *testing.T) {
+	page := parseQuery(t, "status=broken&type=internal&sort=latency&order=desc").Apply(queryLinks)
+	got := linkUrls(page.Links)
+	expected := []string{"https://example.com/c", "https://example.com/e"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if page.Pagination.MatchingCount != 2 {
+		t.Errorf("expected 2 matching links, got %d", page.Pagination.MatchingCount)
+	}
+
+	page = parseQuery(t, "sort=status").Apply(queryLinks)
+	if page.Links[0].StatusCode != 0 || page.Links[4].StatusCode != 500 {
+		t.Errorf("expected links sorted by status, got %v", linkUrls(page.Links))
+	}
+}
+
+func TestLinkQuery_Pagination(t *testing.T) {
+	page := parseQuery(t, "sort=url&limit=2").Apply(queryLinks)
+	if got := linkUrls(page.Links); len(got) != 2 || got[0] != "https://example.com/a" {
+		t.Errorf("unexpected first page %v", got)
+	}
+	if page.Pagination.NextCursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	var all []string
+	all = append(all, linkUrls(page.Links)...)
+	for page.Pagination.NextCursor != "" {
+		page = parseQuery(t, "sort=url&limit=2&cursor="+page.Pagination.NextCursor).Apply(queryLinks)
+		all = append(all, linkUrls(page.Links)...)
+	}
+	if len(all) != len(queryLinks) {
+		t.Errorf("expected the cursors to walk all %d links, got %v", len(queryLinks), all)
+	}
+
+	page = parseQuery(t, "offset=10").Apply(queryLinks)
+	if len(page.Links) != 0 || page.Pagination.MatchingCount != 5 {
+		t.Errorf("expected an empty page past the end, got %v", linkUrls(page.Links))
+	}
+}
+
+func TestParseLinkQuery_Invalid(t *testing.T) {
+	for _, raw := range []string{"status=gone", "type=partner", "sort=size", "order=up", "limit=-1", "limit=5000", "offset=x", "cursor=nope"} {
+		values, _ := url.ParseQuery(raw)
+		if _, err := analyzers.ParseLinkQuery(values); err == nil {
+			t.Errorf("expected an error for %q", raw)
+		}
+	}
+	if !parseQuery(t, "").IsZero() {
+		t.Error("expected an empty query to keep every link")
+	}
+}
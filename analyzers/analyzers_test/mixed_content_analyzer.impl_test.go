@@ -0,0 +1,66 @@
+package analyzers_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+func TestMixedContentAnalyzer(t *testing.T) {
+	html := `<html><head>
+		<link rel="stylesheet" href="http://cdn.example.com/site.css">
+		<link rel="canonical" href="http://example.com/page">
+		<script src="http://cdn.example.com/app.js"></script>
+		<script src="/secure.js"></script>
+		<style>.hero { background: url('http://img.example.com/hero.png'); }</style>
+	</head><body>
+		<img src="http://img.example.com/a.png" srcset="https://img.example.com/b.png 1x, http://img.example.com/c.png 2x">
+		<iframe src="http://widgets.example.com/frame"></iframe>
+		<div style="background-image: url(http://img.example.com/bg.jpg)"></div>
+		<form action="http://example.com/login"></form>
+		<form action="/search"></form>
+	</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+	doc.Url, _ = url.Parse("https://example.com/")
+
+	result := analyzers.MixedContentAnalyzer().Analyze(doc, html)
+	if result.Key != "mixedContent" {
+		t.Errorf("expected key 'mixedContent', got %q", result.Key)
+	}
+
+	report, ok := result.Value.(models.MixedContentReport)
+	if !ok {
+		t.Fatalf("unexpected value type %T", result.Value)
+	}
+	if report.ActiveCount != 3 {
+		t.Errorf("expected 3 active items, got %d: %v", report.ActiveCount, report.Active)
+	}
+	if report.PassiveCount != 4 {
+		t.Errorf("expected 4 passive items, got %d: %v", report.PassiveCount, report.Passive)
+	}
+	if report.InsecureFormCount != 1 || report.InsecureForms[0].Url != "http://example.com/login" {
+		t.Errorf("expected one insecure form, got %v", report.InsecureForms)
+	}
+}
+
+func TestMixedContentAnalyzer_HttpPage(t *testing.T) {
+	html := `<html><body><script src="http://cdn.example.com/app.js"></script></body></html>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+	doc.Url, _ = url.Parse("http://example.com/")
+
+	report := analyzers.MixedContentAnalyzer().Analyze(doc, html).Value.(models.MixedContentReport)
+	if report.PageIsHTTPS || report.ActiveCount != 0 {
+		t.Errorf("expected no mixed content on http page, got %+v", report)
+	}
+}
@@ -0,0 +1,67 @@
+package analyzers_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+func TestPerformanceAnalyzer(t *testing.T) {
+	html := `<html><head>
+		<link rel="stylesheet" href="/site.css">
+		<link rel="stylesheet" href="/print.css" media="print">
+		<link rel="preload" href="/fonts/inter.woff2" as="font">
+		<script src="/blocking.js"></script>
+		<script src="/deferred.js" defer></script>
+	</head><body>
+		<img src="/1.png" width="10" height="10">
+		<img src="/2.png" width="10" height="10">
+		<img src="/3.png" width="10" height="10">
+		<img src="/4.png">
+		<img src="/5.png" width="10" height="10" loading="lazy">
+	</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+	doc.Url, _ = url.Parse("https://example.com/")
+
+	result := analyzers.PerformanceAnalyzer(fakeChecker(500), 300, "gzip").Analyze(doc, html)
+	if result.Key != "performance" {
+		t.Errorf("expected key 'performance', got %q", result.Key)
+	}
+
+	report := result.Value.(models.PerformanceReport)
+	if report.Resources["images"].Count != 5 || report.Resources["scripts"].Count != 2 ||
+		report.Resources["stylesheets"].Count != 2 || report.Resources["fonts"].Count != 1 {
+		t.Errorf("unexpected resource counts %+v", report.Resources)
+	}
+	if report.TotalKnownBytes != 300+10*500 {
+		t.Errorf("expected %d known bytes, got %d", 300+10*500, report.TotalKnownBytes)
+	}
+
+	findings := make(map[string]models.Finding)
+	for _, f := range report.Findings {
+		findings[f.RuleID] = f
+	}
+	if f := findings["perf-render-blocking"]; f.Count != 2 {
+		t.Errorf("expected 2 render blocking resources, got %+v", f)
+	}
+	if f := findings["perf-image-no-dimensions"]; f.Count != 1 {
+		t.Errorf("expected 1 unsized image, got %+v", f)
+	}
+	if f := findings["perf-image-not-lazy"]; f.Count != 1 {
+		t.Errorf("expected 1 eager below the fold image, got %+v", f)
+	}
+	if _, ok := findings["perf-uncompressed-html"]; ok {
+		t.Errorf("did not expect compression finding for gzip page")
+	}
+	if report.Score != 100-10-2-2 {
+		t.Errorf("expected score 86, got %d", report.Score)
+	}
+}
@@ -0,0 +1,116 @@
+package analyzers_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+func TestTechStackAnalyzer(t *testing.T) {
+	html := `<html><head>
+		<meta name="generator" content="WordPress 6.4.2">
+		<script src="/wp-includes/js/jquery/jquery.min.js"></script>
+		<script src="https://www.googletagmanager.com/gtm.js?id=GTM-XXXX"></script>
+	</head><body></body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set("Server", "nginx/1.25.3")
+	header.Set("CF-Ray", "8a1b2c3d4e5f-LHR")
+	cookies := []*http.Cookie{{Name: "_ga", Value: "GA1.1.123"}}
+
+	result := analyzers.TechStackAnalyzer(header, cookies).Analyze(doc, html)
+	if result.Key != "technologies" {
+		t.Errorf("expected key 'technologies', got %q", result.Key)
+	}
+
+	found := make(map[string]models.TechMatch)
+	for _, tm := range result.Value.([]models.TechMatch) {
+		found[tm.Name] = tm
+	}
+
+	for _, name := range []string{"WordPress", "PHP", "jQuery", "Google Tag Manager", "Google Analytics", "Nginx", "Cloudflare"} {
+		if _, ok := found[name]; !ok {
+			t.Errorf("expected %s to be detected, got %v", name, found)
+		}
+	}
+	if wp := found["WordPress"]; wp.Version != "6.4.2" || wp.Confidence != 100 || len(wp.Evidence) == 0 {
+		t.Errorf("unexpected WordPress match %+v", wp)
+	}
+	if nginx := found["Nginx"]; nginx.Version != "1.25.3" {
+		t.Errorf("expected nginx version 1.25.3, got %q", nginx.Version)
+	}
+	if php := found["PHP"]; php.Evidence[0].Source != "implies" {
+		t.Errorf("expected PHP to be implied, got %+v", php)
+	}
+}
+
+func TestParseTechSignatures(t *testing.T) {
+	data := `{
+		"categories": {"1": {"name": "CMS"}},
+		"technologies": {
+			"Acme CMS": {"cats": [1], "meta": {"generator": "^Acme ([\\d.]+)\\;version:\\1\\;confidence:40"}},
+			"Broken": {"cats": [1], "html": "(?<=lookbehind)"}
+		}
+	}`
+
+	sigs, err := analyzers.ParseTechSignatures([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sigs.Technologies) != 2 || sigs.Technologies[0].Categories[0] != "CMS" {
+		t.Fatalf("unexpected signatures %+v", sigs.Technologies)
+	}
+
+	if _, err := analyzers.ParseTechSignatures([]byte("{")); err == nil {
+		t.Errorf("expected error for invalid json")
+	}
+}
+
+// test js patterns apply to the value assigned to the global, and long evidence is cut on a rune boundary
+func TestTechStackAnalyzerJsValues(t *testing.T) {
+	sigs, err := analyzers.ParseTechSignatures([]byte(`{
+		"categories": {"12": {"name": "JavaScript frameworks"}},
+		"technologies": {
+			"Acme UI": {"cats": [12], "js": {"AcmeUI.version": "^([\\d.]+)$\\;version:\\1"}},
+			"Other UI": {"cats": [12], "js": {"OtherUI.version": "^9\\."}},
+			"Acme Theme": {"cats": [12], "meta": {"theme": "^Acme"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	analyzers.SetTechSignatures(sigs)
+	defer analyzers.SetTechSignatures(nil)
+
+	html := `<html><head><meta name="theme" content="Acme ` + strings.Repeat("é", 100) + `">
+		<script>window.AcmeUI = {}; AcmeUI.version = "2.4.1"; OtherUI.version = "1.0";</script>
+	</head><body></body></html>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+
+	found := make(map[string]models.TechMatch)
+	for _, tm := range analyzers.TechStackAnalyzer(http.Header{}, nil).Analyze(doc, html).Value.([]models.TechMatch) {
+		found[tm.Name] = tm
+	}
+	if acme := found["Acme UI"]; acme.Version != "2.4.1" || acme.Evidence[0].Value != "2.4.1" {
+		t.Errorf("expected the assigned version, got %+v", acme)
+	}
+	if _, ok := found["Other UI"]; ok {
+		t.Errorf("expected a value not matching the pattern not to be detected")
+	}
+	if theme := found["Acme Theme"]; !utf8.ValidString(theme.Evidence[0].Value) || !strings.HasSuffix(theme.Evidence[0].Value, "...") {
+		t.Errorf("expected valid truncated evidence, got %q", theme.Evidence[0].Value)
+	}
+}
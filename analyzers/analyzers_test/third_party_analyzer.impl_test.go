@@ -0,0 +1,88 @@
+package analyzers_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+// roundTripFunc serves requests without touching the network
+type roundTripFunc func(req *http.Request) *http.Response
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req), nil
+}
+
+// fakeChecker answers every check with 200 and the given content length
+func fakeChecker(contentLength int64) *fetcher.LinkChecker {
+	return fetcher.NewLinkCheckerWithClient(&http.Client{
+		Transport: roundTripFunc(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				ContentLength: contentLength,
+				Header:        http.Header{},
+				Body:          io.NopCloser(strings.NewReader("")),
+				Request:       req,
+			}
+		}),
+	})
+}
+
+func TestThirdPartyAnalyzer(t *testing.T) {
+	html := `<html><head>
+		<script src="https://www.googletagmanager.com/gtag/js?id=G-1"></script>
+		<script src="https://cdn.jsdelivr.net/npm/lib.js"></script>
+		<link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/lib.css">
+		<script src="https://static.example.com/app.js"></script>
+	</head><body>
+		<iframe src="https://www.youtube.com/embed/abc"></iframe>
+		<img src="https://cdn.jsdelivr.net/npm/lib.js" alt="">
+		<img src="https://unlisted-tracker.io/p.gif" width="1" height="1">
+		<noscript><img src="https://www.facebook.com/tr?id=1&ev=PageView" height="1" width="1"></noscript>
+	</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to create goquery document: %v", err)
+	}
+	doc.Url, _ = url.Parse("https://www.example.com/")
+
+	result := analyzers.ThirdPartyAnalyzer(fakeChecker(1000)).Analyze(doc, html)
+	if result.Key != "thirdParties" {
+		t.Errorf("expected key 'thirdParties', got %q", result.Key)
+	}
+
+	report := result.Value.(models.ThirdPartyReport)
+	if report.DomainCount != 5 || report.RequestCount != 6 {
+		t.Fatalf("expected 5 domains and 6 requests, got %d and %d: %+v", report.DomainCount, report.RequestCount, report.Domains)
+	}
+	if report.TotalKnownBytes != 6000 {
+		t.Errorf("expected 6000 known bytes, got %d", report.TotalKnownBytes)
+	}
+
+	expected := map[string]string{
+		"googletagmanager.com": "analytics",
+		"jsdelivr.net":         "cdn",
+		"youtube.com":          "social",
+		"facebook.com":         "social",
+		"unlisted-tracker.io":  "unknown",
+	}
+	for _, domain := range report.Domains {
+		if expected[domain.Domain] != domain.Category {
+			t.Errorf("expected %s to be %q, got %q", domain.Domain, expected[domain.Domain], domain.Category)
+		}
+		if domain.Domain == "jsdelivr.net" && (domain.KindCounts["script"] != 1 || domain.KindCounts["image"] != 0) {
+			t.Errorf("expected a script referenced again to keep its kind, got %v", domain.KindCounts)
+		}
+		if domain.Domain == "facebook.com" && domain.KindCounts["pixel"] != 1 {
+			t.Errorf("expected facebook pixel, got %v", domain.KindCounts)
+		}
+	}
+}
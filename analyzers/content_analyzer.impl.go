@@ -0,0 +1,198 @@
+package analyzers
+
+import (
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+const (
+	// Average adult silent reading speed
+	wordsPerMinute = 238
+
+	topKeywordCount = 10
+	minKeywordLen   = 3
+	minNgramCount   = 2
+)
+
+// Sentence ends at terminal punctuation or at the end of a text block
+var sentenceEndRegex = regexp.MustCompile(`[.!?…]+["')\]]*(\s|$)|\n`)
+
+// Common english words left out of the keyword stats
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a about above after again against all am an and any are as at be because been
+		before being below between both but by can could did do does doing down during each few for from further had
+		has have having he her here hers herself him himself his how i if in into is it its itself just me more most
+		my myself no nor not now of off on once only or other our ours ourselves out over own same she should so some
+		such than that the their theirs them themselves then there these they this those through to too under until
+		up very was we were what when where which while who whom why will with would you your yours yourself
+		yourselves also may might must shall us get got one two new use used using via per etc`) {
+		stopWords[w] = true
+	}
+}
+
+type contentAnalyzer struct{}
+
+// Construct function to content analyzer
+func ContentAnalyzer() Analyzer {
+	return &contentAnalyzer{}
+}
+
+func (a contentAnalyzer) Analyze(doc *goquery.Document, raw string) Result {
+
+	startTime := time.Now()
+	log.Println("Content analyzer started")
+	defer func(start time.Time) {
+		log.Printf("Content analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
+	}(startTime)
+
+	text := extractVisibleText(doc)
+	words := splitWords(text)
+
+	report := models.ContentReport{
+		WordCount:      len(words),
+		CharacterCount: len([]rune(text)),
+		Keywords:       []models.KeywordStat{},
+		Bigrams:        []models.KeywordStat{},
+		Trigrams:       []models.KeywordStat{},
+	}
+	if len(raw) > 0 {
+		report.TextToHTMLRatio = round2(float64(len(text)) / float64(len(raw)) * 100)
+	}
+	if len(words) == 0 {
+		return Result{Key: "content", Value: report}
+	}
+
+	report.SentenceCount = countSentences(text)
+	report.ReadingTimeSeconds = int(math.Ceil(float64(len(words)) / wordsPerMinute * 60))
+
+	syllables := 0
+	for _, w := range words {
+		syllables += countSyllables(w)
+	}
+	wordsPerSentence := float64(len(words)) / float64(report.SentenceCount)
+	syllablesPerWord := float64(syllables) / float64(len(words))
+	report.AvgWordsPerSentence = round2(wordsPerSentence)
+	report.FleschReadingEase = round2(206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord)
+	report.FleschKincaidGrade = round2(0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59)
+
+	lower := make([]string, len(words))
+	for i, w := range words {
+		lower[i] = strings.ToLower(w)
+	}
+	report.Keywords = topTerms(keywordCounts(lower), len(words), 1)
+	report.Bigrams = topTerms(ngramCounts(lower, 2), len(words), minNgramCount)
+	report.Trigrams = topTerms(ngramCounts(lower, 3), len(words), minNgramCount)
+
+	return Result{Key: "content", Value: report}
+}
+
+// countSentences counts the text segments that contain at least one word
+func countSentences(text string) int {
+	count := 0
+	for _, segment := range sentenceEndRegex.Split(text, -1) {
+		if wordRegex.MatchString(segment) {
+			count++
+		}
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// countSyllables estimates the syllables of an english word from its vowel groups
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	prevVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+	// Silent trailing e, as in "make", but not "the" or "table"
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// keywordCounts counts the words that are not stop words, numbers or too short
+func keywordCounts(words []string) map[string]int {
+	counts := make(map[string]int)
+	for _, w := range words {
+		if isKeyword(w) {
+			counts[w]++
+		}
+	}
+	return counts
+}
+
+// ngramCounts counts the n word phrases that start and end with a keyword
+func ngramCounts(words []string, n int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i+n <= len(words); i++ {
+		if !isKeyword(words[i]) || !isKeyword(words[i+n-1]) {
+			continue
+		}
+		counts[strings.Join(words[i:i+n], " ")]++
+	}
+	return counts
+}
+
+func isKeyword(word string) bool {
+	if len([]rune(word)) < minKeywordLen || stopWords[word] {
+		return false
+	}
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// topTerms returns the most frequent terms with their density against totalWords
+func topTerms(counts map[string]int, totalWords int, minCount int) []models.KeywordStat {
+	stats := []models.KeywordStat{}
+	for term, count := range counts {
+		if count < minCount {
+			continue
+		}
+		stats = append(stats, models.KeywordStat{
+			Term:    term,
+			Count:   count,
+			Density: round2(float64(count) / float64(totalWords) * 100),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Term < stats[j].Term
+	})
+	if len(stats) > topKeywordCount {
+		stats = stats[:topKeywordCount]
+	}
+	return stats
+}
+
+// round2 rounds to two decimals
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
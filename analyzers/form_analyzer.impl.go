@@ -0,0 +1,38 @@
+package analyzers
+
+import (
+	"log"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+type formAnalyzer struct{}
+
+// Construct function to form analyzer
+func FormAnalyzer() Analyzer {
+	return &formAnalyzer{}
+}
+
+func (a formAnalyzer) Analyze(doc *goquery.Document, _ string) Result {
+
+	startTime := time.Now()
+	log.Println("Form analyzer started")
+	defer func(start time.Time) {
+		log.Printf("Form analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
+	}(startTime)
+
+	forms := extractForms(doc)
+	report := models.FormsReport{
+		FormCount:    len(forms),
+		ByType:       make(map[string]int),
+		Forms:        forms,
+		SSOProviders: detectSSOProviders(doc),
+	}
+	for _, form := range forms {
+		report.ByType[form.Classification]++
+	}
+
+	return Result{Key: "forms", Value: report}
+}
@@ -0,0 +1,381 @@
+package analyzers
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+// Form classifications
+const (
+	FormLogin         = "login"
+	FormSignup        = "signup"
+	FormPasswordReset = "password_reset"
+	FormSearch        = "search"
+	FormNewsletter    = "newsletter"
+	FormPayment       = "payment"
+	FormUnknown       = "unknown"
+)
+
+// Below this score a form stays unknown
+const minFormScore = 30
+
+// Input types that are buttons, not fields
+var buttonInputTypes = map[string]bool{
+	"submit": true,
+	"button": true,
+	"image":  true,
+	"reset":  true,
+}
+
+var (
+	loginTextRegex      = regexp.MustCompile(`(?i)\b(log ?in|sign ?in|signin|login)\b`)
+	signupTextRegex     = regexp.MustCompile(`(?i)\b(sign ?up|register|create (an )?account|join( now)?)\b`)
+	resetTextRegex      = regexp.MustCompile(`(?i)\b(reset|forgot|recover|change password|update password)\b`)
+	searchTextRegex     = regexp.MustCompile(`(?i)\bsearch\b`)
+	newsletterTextRegex = regexp.MustCompile(`(?i)\b(subscribe|newsletter)\b`)
+	paymentTextRegex    = regexp.MustCompile(`(?i)\b(pay|checkout|place order|purchase|buy now)\b`)
+
+	loginActionRegex      = regexp.MustCompile(`(?i)(log_?in|sign_?in|session|auth)`)
+	signupActionRegex     = regexp.MustCompile(`(?i)(register|sign_?up|join)`)
+	resetActionRegex      = regexp.MustCompile(`(?i)(reset|forgot|recover|password)`)
+	searchActionRegex     = regexp.MustCompile(`(?i)search`)
+	newsletterActionRegex = regexp.MustCompile(`(?i)(subscribe|newsletter|list-manage|mailchimp)`)
+	paymentActionRegex    = regexp.MustCompile(`(?i)(checkout|payment|pay)`)
+
+	usernameFieldRegex = regexp.MustCompile(`(?i)(user|login|email|e-mail|account)`)
+	nameFieldRegex     = regexp.MustCompile(`(?i)(first.?name|last.?name|full.?name|fname|lname|terms|agree)`)
+	searchFieldRegex   = regexp.MustCompile(`(?i)^(q|s|query|search|keywords?|term)$`)
+	cardFieldRegex     = regexp.MustCompile(`(?i)(card.?number|cc.?num|cvv|cvc|security.?code|expir|exp.?date)`)
+)
+
+// ssoProvider matches single sign on buttons by text or by the link target
+type ssoProvider struct {
+	name string
+	text *regexp.Regexp
+	url  *regexp.Regexp
+}
+
+var ssoProviders = []ssoProvider{
+	{name: "Google", text: regexp.MustCompile(`(?i)(sign|log) ?in with google|continue with google`), url: regexp.MustCompile(`accounts\.google\.com/(o/oauth2|signin)`)},
+	{name: "Microsoft", text: regexp.MustCompile(`(?i)(sign|log) ?in with microsoft|continue with microsoft`), url: regexp.MustCompile(`login\.(microsoftonline|live)\.com`)},
+	{name: "Apple", text: regexp.MustCompile(`(?i)(sign|log) ?in with apple|continue with apple`), url: regexp.MustCompile(`appleid\.apple\.com/auth`)},
+	{name: "Facebook", text: regexp.MustCompile(`(?i)(sign|log) ?in with facebook|continue with facebook`), url: regexp.MustCompile(`facebook\.com/(v[\d.]+/)?dialog/oauth`)},
+	{name: "GitHub", text: regexp.MustCompile(`(?i)(sign|log) ?in with github|continue with github`), url: regexp.MustCompile(`github\.com/login/oauth`)},
+	{name: "LinkedIn", text: regexp.MustCompile(`(?i)(sign|log) ?in with linkedin|continue with linkedin`), url: regexp.MustCompile(`linkedin\.com/oauth`)},
+	{name: "SAML/SSO", text: regexp.MustCompile(`(?i)(sign|log) ?in with (sso|single sign.on)|use single sign.on`), url: regexp.MustCompile(`(?i)/(saml|sso)/`)},
+}
+
+// extractForms lists every form of the document with its fields, buttons and classification
+func extractForms(doc *goquery.Document) []models.FormInfo {
+	forms := []models.FormInfo{}
+	base := documentBase(doc)
+	doc.Find("form").Each(func(i int, s *goquery.Selection) {
+		form := models.FormInfo{
+			Index:   i,
+			Method:  "GET",
+			Hidden:  isHiddenForm(s),
+			Fields:  []models.FormField{},
+			Buttons: []models.FormButton{},
+		}
+		form.Id, _ = s.Attr("id")
+		if method, ok := s.Attr("method"); ok && strings.TrimSpace(method) != "" {
+			form.Method = strings.ToUpper(strings.TrimSpace(method))
+		}
+
+		// An empty action submits to the page itself, not to <base href>
+		action, _ := s.Attr("action")
+		if action = strings.TrimSpace(action); action != "" {
+			form.Action = resolveUrl(base, action)
+		}
+		if form.Action == "" && doc.Url != nil {
+			form.Action = doc.Url.String()
+		}
+
+		s.Find("input, select, textarea, button").Each(func(_ int, f *goquery.Selection) {
+			tag := goquery.NodeName(f)
+			fieldType := strings.ToLower(strings.TrimSpace(f.AttrOr("type", "")))
+			if tag == "button" || (tag == "input" && buttonInputTypes[fieldType]) {
+				if tag == "button" && fieldType == "" {
+					fieldType = "submit"
+				}
+				text := buttonText(f)
+				if text == "" && tag == "input" && fieldType == "submit" {
+					// Browsers label a submit input without value as "Submit"
+					text = "Submit"
+				}
+				form.Buttons = append(form.Buttons, models.FormButton{Text: text, Type: fieldType})
+				return
+			}
+			if tag == "input" && fieldType == "" {
+				fieldType = "text"
+			}
+			if tag != "input" {
+				fieldType = tag
+			}
+
+			field := models.FormField{
+				Tag:          tag,
+				Type:         fieldType,
+				Name:         f.AttrOr("name", ""),
+				Id:           f.AttrOr("id", ""),
+				Autocomplete: strings.ToLower(f.AttrOr("autocomplete", "")),
+				Placeholder:  f.AttrOr("placeholder", ""),
+				Value:        f.AttrOr("value", ""),
+			}
+			_, field.Required = f.Attr("required")
+			field.Label = fieldLabel(doc, f, field.Id)
+			form.Fields = append(form.Fields, field)
+		})
+
+		classifyForm(&form, s)
+		forms = append(forms, form)
+	})
+	return forms
+}
+
+// classifyForm scores the form against every classification and keeps the best one
+func classifyForm(form *models.FormInfo, s *goquery.Selection) {
+	scores := make(map[string]int)
+	signals := make(map[string][]string)
+	score := func(class string, points int, signal string) {
+		scores[class] += points
+		signals[class] = append(signals[class], signal)
+	}
+
+	var passwords, currentPasswords, newPasswords, emails, visible, usernames, names, searches, cards int
+	for _, f := range form.Fields {
+		key := f.Name + " " + f.Id
+		if f.Type != "hidden" {
+			visible++
+		}
+		switch {
+		case f.Type == "password":
+			passwords++
+		case f.Type == "email" || f.Autocomplete == "email" || strings.Contains(strings.ToLower(key), "email"):
+			emails++
+		}
+		switch f.Autocomplete {
+		case "current-password":
+			currentPasswords++
+		case "new-password":
+			newPasswords++
+		case "username":
+			usernames++
+		}
+		if f.Type != "password" && f.Type != "hidden" && usernameFieldRegex.MatchString(key) {
+			usernames++
+		}
+		if nameFieldRegex.MatchString(key) || strings.HasPrefix(f.Autocomplete, "given-name") || strings.HasPrefix(f.Autocomplete, "family-name") {
+			names++
+		}
+		if f.Type == "search" || searchFieldRegex.MatchString(strings.TrimSpace(f.Name)) {
+			searches++
+		}
+		if strings.HasPrefix(f.Autocomplete, "cc-") || cardFieldRegex.MatchString(key) {
+			cards++
+		}
+	}
+
+	var buttons []string
+	for _, b := range form.Buttons {
+		buttons = append(buttons, b.Text)
+	}
+	buttonText := strings.Join(buttons, " ")
+	role, _ := s.Attr("role")
+
+	// Login
+	if passwords == 1 {
+		score(FormLogin, 35, "single password field")
+	}
+	if currentPasswords > 0 && newPasswords == 0 {
+		score(FormLogin, 40, "autocomplete=current-password")
+	}
+	if passwords > 0 && usernames > 0 {
+		score(FormLogin, 15, "username or email field")
+	}
+	if loginTextRegex.MatchString(buttonText) {
+		score(FormLogin, 30, "login button text")
+	}
+	if loginActionRegex.MatchString(form.Action) {
+		score(FormLogin, 15, "login action url")
+	}
+	if visible > 5 {
+		score(FormLogin, -20, "many fields")
+	}
+
+	// Signup
+	if newPasswords > 0 && currentPasswords == 0 {
+		score(FormSignup, 40, "autocomplete=new-password")
+	}
+	if passwords == 2 && currentPasswords == 0 {
+		score(FormSignup, 35, "password confirmation field")
+	}
+	if signupTextRegex.MatchString(buttonText) {
+		score(FormSignup, 35, "signup button text")
+	}
+	if names > 0 && passwords > 0 {
+		score(FormSignup, 15, "name or terms fields")
+	}
+	if signupActionRegex.MatchString(form.Action) {
+		score(FormSignup, 15, "signup action url")
+	}
+
+	// Password reset or change
+	if resetTextRegex.MatchString(buttonText) {
+		score(FormPasswordReset, 40, "reset button text")
+	}
+	if resetActionRegex.MatchString(form.Action) && !loginActionRegex.MatchString(form.Action) {
+		score(FormPasswordReset, 25, "reset action url")
+	}
+	if currentPasswords > 0 && newPasswords > 0 {
+		score(FormPasswordReset, 60, "current and new password fields")
+	}
+	if passwords == 0 && emails == 1 && visible == 1 && resetTextRegex.MatchString(buttonText) {
+		score(FormPasswordReset, 10, "single email field")
+	}
+
+	// Search
+	if searches > 0 {
+		score(FormSearch, 45, "search field")
+	}
+	if strings.EqualFold(role, "search") {
+		score(FormSearch, 30, "role=search")
+	}
+	if searchTextRegex.MatchString(buttonText) {
+		score(FormSearch, 25, "search button text")
+	}
+	if searchActionRegex.MatchString(form.Action) {
+		score(FormSearch, 15, "search action url")
+	}
+	if form.Method == "GET" && visible == 1 && passwords == 0 {
+		score(FormSearch, 10, "single field GET form")
+	}
+
+	// Newsletter
+	if passwords == 0 && emails == 1 && visible <= 2 {
+		score(FormNewsletter, 25, "single email field")
+	}
+	if newsletterTextRegex.MatchString(buttonText) {
+		score(FormNewsletter, 40, "subscribe button text")
+	}
+	if newsletterActionRegex.MatchString(form.Action) {
+		score(FormNewsletter, 30, "newsletter action url")
+	}
+
+	// Payment
+	if cards > 0 {
+		score(FormPayment, 50, "card fields")
+	}
+	if paymentTextRegex.MatchString(buttonText) {
+		score(FormPayment, 30, "payment button text")
+	}
+	if paymentActionRegex.MatchString(form.Action) {
+		score(FormPayment, 20, "payment action url")
+	}
+
+	form.Classification = FormUnknown
+	form.Signals = []string{}
+	best := 0
+	for _, class := range []string{FormLogin, FormSignup, FormPasswordReset, FormPayment, FormSearch, FormNewsletter} {
+		if scores[class] > best {
+			best = scores[class]
+			form.Classification = class
+		}
+	}
+	if best < minFormScore {
+		form.Classification = FormUnknown
+		return
+	}
+
+	form.Confidence = best
+	if form.Confidence > 100 {
+		form.Confidence = 100
+	}
+	// Hidden templates are not what the visitor sees
+	if form.Hidden {
+		form.Confidence /= 2
+		signals[form.Classification] = append(signals[form.Classification], "hidden form")
+	}
+	form.Signals = signals[form.Classification]
+}
+
+// detectSSOProviders finds "Sign in with ..." buttons and links to known OAuth endpoints
+func detectSSOProviders(doc *goquery.Document) []models.SSOButton {
+	buttons := []models.SSOButton{}
+	seen := make(map[string]bool)
+	base := documentBase(doc)
+	doc.Find("a, button, input[type=submit], input[type=button], [role=button]").Each(func(_ int, s *goquery.Selection) {
+		text := buttonText(s)
+		href := resolveUrl(base, strings.TrimSpace(s.AttrOr("href", "")))
+		for _, p := range ssoProviders {
+			if !p.text.MatchString(text) && (href == "" || !p.url.MatchString(href)) {
+				continue
+			}
+			if seen[p.name] {
+				return
+			}
+			seen[p.name] = true
+			buttons = append(buttons, models.SSOButton{Provider: p.name, Text: text, Url: href})
+			return
+		}
+	})
+	sort.Slice(buttons, func(i, j int) bool { return buttons[i].Provider < buttons[j].Provider })
+	return buttons
+}
+
+// buttonText returns the visible or accessible text of a button like element
+func buttonText(s *goquery.Selection) string {
+	text := strings.Join(strings.Fields(s.Text()), " ")
+	if text == "" {
+		text = strings.TrimSpace(s.AttrOr("value", ""))
+	}
+	if text == "" {
+		text = strings.TrimSpace(s.AttrOr("aria-label", ""))
+	}
+	if text == "" {
+		text = strings.TrimSpace(s.AttrOr("title", ""))
+	}
+	if text == "" {
+		text = strings.TrimSpace(s.AttrOr("alt", ""))
+	}
+	if text == "" {
+		text = strings.TrimSpace(s.Find("img[alt]").AttrOr("alt", ""))
+	}
+	return text
+}
+
+// fieldLabel returns the label text of a field, from label[for] or a wrapping label
+func fieldLabel(doc *goquery.Document, f *goquery.Selection, id string) string {
+	if id != "" {
+		if label := doc.Find(`label[for="` + id + `"]`); label.Length() > 0 {
+			return strings.Join(strings.Fields(label.First().Text()), " ")
+		}
+	}
+	if label := f.Closest("label"); label.Length() > 0 {
+		return strings.Join(strings.Fields(label.Text()), " ")
+	}
+	return strings.TrimSpace(f.AttrOr("aria-label", ""))
+}
+
+// isHiddenForm reports whether the form is inside a template or hidden with markup
+func isHiddenForm(s *goquery.Selection) bool {
+	if s.Closest("template").Length() > 0 {
+		return true
+	}
+	hidden := false
+	s.ParentsUntil("html").AddSelection(s).EachWithBreak(func(_ int, el *goquery.Selection) bool {
+		style := strings.ReplaceAll(strings.ToLower(el.AttrOr("style", "")), " ", "")
+		_, hasHidden := el.Attr("hidden")
+		if hasHidden || el.AttrOr("aria-hidden", "") == "true" ||
+			strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+			hidden = true
+			return false
+		}
+		return true
+	})
+	return hidden
+}
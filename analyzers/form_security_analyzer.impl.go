@@ -0,0 +1,154 @@
+package analyzers
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+// Hidden input names that look like anti CSRF tokens
+var csrfTokenRegex = regexp.MustCompile(`(?i)(csrf|xsrf|authenticity_token|requestverificationtoken|^_?token$|nonce|form_key)`)
+
+// formRule describes one form check
+type formRule struct {
+	id       string
+	severity string
+	message  string
+}
+
+var (
+	ruleInsecureAction     = formRule{"form-password-insecure-action", models.SeverityError, "Password form submits over plain HTTP"}
+	ruleCrossOriginAction  = formRule{"form-password-cross-origin", models.SeverityWarning, "Password form submits to another origin"}
+	rulePasswordGet        = formRule{"form-password-get", models.SeverityError, "Password form uses GET, the password ends up in the url"}
+	ruleMissingCsrf        = formRule{"form-missing-csrf-token", models.SeverityWarning, "POST form has no CSRF token like hidden input"}
+	ruleAutocompleteOff    = formRule{"form-password-autocomplete-off", models.SeverityWarning, "Password field disables autocomplete, which blocks password managers"}
+	ruleInputMissingName   = formRule{"form-input-missing-name", models.SeverityInfo, "Input has no name and is not submitted"}
+	ruleButtonNoText       = formRule{"form-button-no-text", models.SeverityWarning, "Submit button has no accessible text"}
+	formRulesInReportOrder = []formRule{ruleInsecureAction, rulePasswordGet, ruleCrossOriginAction, ruleMissingCsrf, ruleAutocompleteOff, ruleButtonNoText, ruleInputMissingName}
+)
+
+type formSecurityAnalyzer struct{}
+
+// Construct function to form security analyzer
+func FormSecurityAnalyzer() Analyzer {
+	return &formSecurityAnalyzer{}
+}
+
+func (a formSecurityAnalyzer) Analyze(doc *goquery.Document, _ string) Result {
+
+	startTime := time.Now()
+	log.Println("Form security analyzer started")
+	defer func(start time.Time) {
+		log.Printf("Form security analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
+	}(startTime)
+
+	forms := extractForms(doc)
+
+	// Items per rule, each item names the form and field
+	items := make(map[string][]string)
+	flag := func(rule formRule, item string) {
+		items[rule.id] = append(items[rule.id], item)
+	}
+
+	for _, form := range forms {
+		formName := fmt.Sprintf("form[%d]", form.Index)
+		if form.Id != "" {
+			formName = "form#" + form.Id
+		}
+
+		hasPassword := false
+		hasCsrfToken := false
+		for _, f := range form.Fields {
+			fieldName := formName + " " + f.Type
+			if f.Name != "" {
+				fieldName += "[name=" + f.Name + "]"
+			}
+
+			if f.Type == "password" {
+				hasPassword = true
+				if f.Autocomplete == "off" {
+					flag(ruleAutocompleteOff, fieldName)
+				}
+			}
+			if f.Type == "hidden" && (csrfTokenRegex.MatchString(f.Name) || csrfTokenRegex.MatchString(f.Id)) {
+				hasCsrfToken = true
+			}
+			if f.Name == "" {
+				flag(ruleInputMissingName, fieldName)
+			}
+		}
+
+		for i, b := range form.Buttons {
+			if (b.Type == "submit" || b.Type == "image") && b.Text == "" {
+				flag(ruleButtonNoText, fmt.Sprintf("%s button[%d]", formName, i))
+			}
+		}
+
+		if form.Method != "GET" && !hasCsrfToken {
+			flag(ruleMissingCsrf, formName)
+		}
+
+		if !hasPassword {
+			continue
+		}
+		action, err := url.Parse(form.Action)
+		if err != nil {
+			continue
+		}
+		if action.Scheme == "http" {
+			flag(ruleInsecureAction, formName+" -> "+form.Action)
+		}
+		if form.Method == "GET" {
+			flag(rulePasswordGet, formName)
+		}
+		if doc.Url != nil && action.Host != "" && !sameOrigin(doc.Url, action) {
+			flag(ruleCrossOriginAction, formName+" -> "+form.Action)
+		}
+	}
+
+	report := models.FormSecurityReport{
+		FormCount:      len(forms),
+		SeverityCounts: make(map[string]int),
+		Findings:       []models.Finding{},
+	}
+	for _, rule := range formRulesInReportOrder {
+		if len(items[rule.id]) == 0 {
+			continue
+		}
+		report.Findings = append(report.Findings, models.Finding{
+			RuleID:   rule.id,
+			Severity: rule.severity,
+			Message:  rule.message,
+			Count:    len(items[rule.id]),
+			Items:    items[rule.id],
+		})
+		report.SeverityCounts[rule.severity] += len(items[rule.id])
+	}
+
+	return Result{Key: "formSecurity", Value: report}
+}
+
+// sameOrigin compares scheme, host and port of two urls
+func sameOrigin(a *url.URL, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Hostname(), b.Hostname()) && originPort(a) == originPort(b)
+}
+
+// originPort returns the port of u, the default port of its scheme when it has none
+func originPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
@@ -2,6 +2,7 @@ package analyzers
 
 import (
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -26,7 +27,7 @@ func (a headingAnalyzer) Analyze(doc *goquery.Document, _ string) Result {
 
 	// I'm using GoQuery for this Google suggests it's best tools for tag analysis.
 	for i := 1; i <= 6; i++ {
-		tag := "h" + string('0'+i)
+		tag := "h" + strconv.Itoa(i)
 		var tagContents []string
 		doc.Find(tag).Each(func(_ int, s *goquery.Selection) {
 			text := s.Text()
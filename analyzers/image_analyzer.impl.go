@@ -0,0 +1,220 @@
+package analyzers
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+const (
+	oversizedImageBytes = 200 * 1024
+	// Smaller images are not worth a srcset or a modern format
+	minOptimizableImageBytes = 10 * 1024
+)
+
+// Formats a browser can replace with WebP or AVIF
+var legacyImageFormats = map[string]bool{
+	"jpeg": true,
+	"png":  true,
+	"gif":  true,
+	"bmp":  true,
+	"tiff": true,
+}
+
+type imageAnalyzer struct {
+	checker *fetcher.LinkChecker
+}
+
+// Construct function to image analyzer, formats and sizes come from the shared checker
+func ImageAnalyzer(checker *fetcher.LinkChecker) Analyzer {
+	if checker == nil {
+		checker = fetcher.NewLinkChecker(10 * time.Second)
+	}
+	return &imageAnalyzer{checker: checker}
+}
+
+func (a imageAnalyzer) Analyze(doc *goquery.Document, _ string) Result {
+
+	startTime := time.Now()
+	log.Println("Image analyzer started")
+	defer func(start time.Time) {
+		log.Printf("Image analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
+	}(startTime)
+
+	images := collectImages(doc)
+
+	// Formats and sizes from the link checks
+	var wg sync.WaitGroup
+	for i := range images {
+		if !strings.HasPrefix(images[i].Url, "http") {
+			continue
+		}
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			status := a.checker.Check(images[idx].Url)
+			images[idx].Bytes = status.ContentLength
+			if format := imageFormat(status.ContentType); format != "" {
+				images[idx].Format = format
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	report := models.ImageReport{
+		ImageCount:   len(images),
+		FormatCounts: make(map[string]int),
+		Images:       images,
+		Findings:     []models.Finding{},
+	}
+
+	var oversized, noSrcset, legacy, noAlt []string
+	for _, img := range images {
+		report.FormatCounts[img.Format]++
+		if img.Bytes > 0 {
+			report.TotalKnownBytes += img.Bytes
+		}
+
+		if img.Bytes > oversizedImageBytes {
+			oversized = append(oversized, fmt.Sprintf("%s (%d KB)", img.Url, img.Bytes/1024))
+		}
+		if img.Source == "img" && !img.HasSrcset && !img.InPicture && img.Format != "svg" && img.Bytes > minOptimizableImageBytes {
+			noSrcset = append(noSrcset, img.Url)
+		}
+		if legacyImageFormats[img.Format] && !img.InPicture && img.Bytes > minOptimizableImageBytes {
+			legacy = append(legacy, img.Url)
+		}
+		if img.Source == "img" && !img.HasAlt {
+			noAlt = append(noAlt, img.Url)
+		}
+	}
+
+	addFinding := func(ruleID string, severity string, message string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		report.Findings = append(report.Findings, models.Finding{
+			RuleID:   ruleID,
+			Severity: severity,
+			Message:  message,
+			Count:    len(items),
+			Items:    items,
+		})
+	}
+	addFinding("img-oversized", models.SeverityWarning, fmt.Sprintf("Images larger than %d KB", oversizedImageBytes/1024), oversized)
+	addFinding("img-missing-srcset", models.SeverityInfo, "Images without a responsive srcset", noSrcset)
+	addFinding("img-legacy-format", models.SeverityInfo, "JPEG, PNG or GIF images that could be served as WebP or AVIF", legacy)
+	addFinding("img-missing-alt", models.SeverityWarning, "Images without an alt attribute", noAlt)
+
+	return Result{Key: "images", Value: report}
+}
+
+// collectImages lists the images of img, picture sources, srcset candidates and inline css backgrounds
+func collectImages(doc *goquery.Document) []models.ImageInfo {
+	images := []models.ImageInfo{}
+	seen := make(map[string]bool)
+	base := documentBase(doc)
+	add := func(img models.ImageInfo, ref string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" || strings.HasPrefix(strings.ToLower(ref), "data:") {
+			return
+		}
+		img.Url = resolveUrl(base, ref)
+		key := img.Source + " " + img.Url
+		if img.Url == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		img.Bytes = -1
+		img.Format = imageFormatFromUrl(img.Url)
+		images = append(images, img)
+	}
+
+	doc.Find("img").Each(func(_ int, s *goquery.Selection) {
+		alt, hasAlt := s.Attr("alt")
+		srcset, hasSrcset := s.Attr("srcset")
+		base := models.ImageInfo{
+			Alt:       alt,
+			HasAlt:    hasAlt,
+			Width:     s.AttrOr("width", ""),
+			Height:    s.AttrOr("height", ""),
+			HasSrcset: hasSrcset,
+			InPicture: s.ParentFiltered("picture").Length() > 0,
+		}
+
+		img := base
+		img.Source = "img"
+		add(img, s.AttrOr("src", ""))
+
+		for _, ref := range parseSrcset(srcset) {
+			img := base
+			img.Source = "srcset"
+			add(img, ref)
+		}
+	})
+
+	doc.Find("picture source[srcset]").Each(func(_ int, s *goquery.Selection) {
+		for _, ref := range parseSrcset(s.AttrOr("srcset", "")) {
+			add(models.ImageInfo{Source: "picture", HasSrcset: true, InPicture: true}, ref)
+		}
+	})
+
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		for _, ref := range parseCssUrls(s.AttrOr("style", "")) {
+			add(models.ImageInfo{Source: "css"}, ref)
+		}
+	})
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		for _, ref := range parseCssUrls(s.Text()) {
+			if format := imageFormatFromUrl(resolveUrl(base, ref)); format != "unknown" {
+				add(models.ImageInfo{Source: "css"}, ref)
+			}
+		}
+	})
+
+	return images
+}
+
+// imageFormat maps an image Content-Type to a short format name
+func imageFormat(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "image/") {
+		return ""
+	}
+	format := strings.TrimPrefix(mediaType, "image/")
+	switch format {
+	case "jpg", "pjpeg":
+		return "jpeg"
+	case "svg+xml":
+		return "svg"
+	case "x-icon", "vnd.microsoft.icon":
+		return "ico"
+	}
+	return format
+}
+
+// imageFormatFromUrl guesses the format from the file extension until the Content-Type is known
+func imageFormatFromUrl(rawUrl string) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return "unknown"
+	}
+	switch ext := strings.ToLower(path.Ext(parsed.Path)); ext {
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".png", ".gif", ".webp", ".avif", ".svg", ".bmp", ".ico":
+		return ext[1:]
+	case ".tif", ".tiff":
+		return "tiff"
+	}
+	return "unknown"
+}
@@ -0,0 +1,175 @@
+package analyzers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+// Detections below this confidence of the model are not reported as mismatches
+const minMismatchConfidence = 0.6
+
+type languageAnalyzer struct {
+	contentLanguage string
+}
+
+// Construct function to language analyzer, contentLanguage is the Content-Language response header
+func LanguageAnalyzer(contentLanguage string) Analyzer {
+	return &languageAnalyzer{contentLanguage: contentLanguage}
+}
+
+func (a languageAnalyzer) Analyze(doc *goquery.Document, _ string) Result {
+
+	startTime := time.Now()
+	log.Println("Language analyzer started")
+	defer func(start time.Time) {
+		log.Printf("Language analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
+	}(startTime)
+
+	text := extractVisibleText(doc)
+	detected, confidence := detectLanguage(text)
+
+	report := models.LanguageReport{
+		DetectedLang:    detected,
+		Confidence:      hintConfidence(confidence),
+		Hint:            true,
+		HTMLLang:        strings.TrimSpace(doc.Find("html").AttrOr("lang", "")),
+		ContentLanguage: strings.TrimSpace(a.contentLanguage),
+		Distribution:    languageDistribution(text),
+		Findings:        []models.Finding{},
+	}
+	var confidentSections []bool
+	report.Sections, confidentSections = languageSections(doc)
+
+	supported := make(map[string]bool)
+	for _, profile := range getLanguageProfiles() {
+		supported[profile.code] = true
+	}
+	confident := detected != UndeterminedLanguage && confidence >= minMismatchConfidence
+
+	htmlLang := primaryLanguage(report.HTMLLang)
+	var headerLangs []string
+	for _, tag := range strings.Split(report.ContentLanguage, ",") {
+		if lang := primaryLanguage(tag); lang != "" {
+			headerLangs = append(headerLangs, lang)
+		}
+	}
+
+	if htmlLang == "" && len(headerLangs) == 0 {
+		report.Findings = append(report.Findings, models.Finding{
+			RuleID:   "lang-missing-declaration",
+			Severity: models.SeverityWarning,
+			Message:  "Page declares no language in <html lang> or Content-Language",
+		})
+	}
+	// The detection is a hint, so disagreeing with a declaration is only reported as info
+	if confident && htmlLang != "" && supported[htmlLang] && htmlLang != detected && !similarLanguage(htmlLang, detected) {
+		report.Findings = append(report.Findings, models.Finding{
+			RuleID:   "lang-html-mismatch",
+			Severity: models.SeverityInfo,
+			Message:  fmt.Sprintf("<html lang> declares %q but the content looks like %q", report.HTMLLang, detected),
+		})
+	}
+	if confident && len(headerLangs) > 0 && !containsString(headerLangs, detected) && supported[headerLangs[0]] && !similarLanguage(headerLangs[0], detected) {
+		report.Findings = append(report.Findings, models.Finding{
+			RuleID:   "lang-header-mismatch",
+			Severity: models.SeverityInfo,
+			Message:  fmt.Sprintf("Content-Language declares %q but the content looks like %q", report.ContentLanguage, detected),
+		})
+	}
+	if htmlLang != "" && len(headerLangs) > 0 && !containsString(headerLangs, htmlLang) {
+		report.Findings = append(report.Findings, models.Finding{
+			RuleID:   "lang-declarations-disagree",
+			Severity: models.SeverityInfo,
+			Message:  fmt.Sprintf("<html lang> %q and Content-Language %q disagree", report.HTMLLang, report.ContentLanguage),
+		})
+	}
+
+	var mismatched []string
+	for i, section := range report.Sections {
+		declared := primaryLanguage(section.DeclaredLang)
+		if declared != "" && supported[declared] && confidentSections[i] && declared != section.DetectedLang && !similarLanguage(declared, section.DetectedLang) {
+			mismatched = append(mismatched, fmt.Sprintf("%s declares %q, content looks like %q", section.Selector, section.DeclaredLang, section.DetectedLang))
+		}
+	}
+	if len(mismatched) > 0 {
+		report.Findings = append(report.Findings, models.Finding{
+			RuleID:   "lang-section-mismatch",
+			Severity: models.SeverityInfo,
+			Message:  "Sections with a lang attribute that does not match their content",
+			Count:    len(mismatched),
+			Items:    mismatched,
+		})
+	}
+
+	return Result{Key: "language", Value: report}
+}
+
+// languageDistribution detects every text block and returns the share of words per language
+func languageDistribution(text string) map[string]float64 {
+	words := make(map[string]int)
+	total := 0
+	for _, block := range strings.Split(text, "\n") {
+		lang, _ := detectLanguage(block)
+		if lang == UndeterminedLanguage {
+			continue
+		}
+		count := len(splitWords(block))
+		words[lang] += count
+		total += count
+	}
+
+	distribution := make(map[string]float64)
+	for lang, count := range words {
+		distribution[lang] = round2(float64(count) / float64(total) * 100)
+	}
+	return distribution
+}
+
+// languageSections detects the language of elements with a lang attribute and of sectioning elements,
+// and whether each detection is confident enough to be compared with the declared language
+func languageSections(doc *goquery.Document) ([]models.LanguageSection, []bool) {
+	sections := []models.LanguageSection{}
+	var confident []bool
+	doc.Find("body [lang], section, article, aside").Each(func(i int, s *goquery.Selection) {
+		text := visibleText(s)
+		lang, confidence := detectLanguage(text)
+		if lang == UndeterminedLanguage {
+			return
+		}
+		sections = append(sections, models.LanguageSection{
+			Selector:     elementSelector(s, i),
+			DeclaredLang: s.AttrOr("lang", ""),
+			DetectedLang: lang,
+			Confidence:   hintConfidence(confidence),
+			WordCount:    len(splitWords(text)),
+		})
+		confident = append(confident, confidence >= minMismatchConfidence)
+	})
+	return sections, confident
+}
+
+// elementSelector returns a short css like name for an element, e.g. section#intro
+func elementSelector(s *goquery.Selection, index int) string {
+	tag := goquery.NodeName(s)
+	if id := s.AttrOr("id", ""); id != "" {
+		return tag + "#" + id
+	}
+	if class := strings.Fields(s.AttrOr("class", "")); len(class) > 0 {
+		return tag + "." + class[0]
+	}
+	return fmt.Sprintf("%s[%d]", tag, index)
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
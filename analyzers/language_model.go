@@ -0,0 +1,161 @@
+package analyzers
+
+import (
+	"embed"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Bundled training texts, one file per ISO 639-1 language code
+//
+//go:embed signatures/languages/*.txt
+var languageCorpus embed.FS
+
+const (
+	// Texts with fewer letters are too short to detect
+	minDetectLetters = 40
+	// Sharpness of the confidence distribution over the per trigram likelihoods
+	languageConfidenceScale = 8
+	// The bundled profiles are trained on about 1.4KB of text per language, so a detection is
+	// only a hint and never reported with more confidence than this
+	maxLanguageHintConfidence = 0.5
+)
+
+// Languages the small profiles confuse, a detection of one where the other is declared is no mismatch
+var similarLanguages = map[string][]string{
+	"es": {"pt"},
+	"pt": {"es"},
+	"da": {"no", "nb", "nn", "sv"},
+	"no": {"da", "nb", "nn", "sv"},
+	"nb": {"da", "no", "nn", "sv"},
+	"nn": {"da", "no", "nb", "sv"},
+	"sv": {"da", "no", "nb", "nn"},
+}
+
+// UndeterminedLanguage is reported when the text is too short or unknown
+const UndeterminedLanguage = "und"
+
+// languageProfile holds the trigram log probabilities of one language
+type languageProfile struct {
+	code       string
+	logProbs   map[string]float64
+	unseenProb float64
+}
+
+var (
+	languageModelOnce sync.Once
+	languageProfiles  []languageProfile
+)
+
+// getLanguageProfiles trains the trigram profiles from the bundled corpus once
+func getLanguageProfiles() []languageProfile {
+	languageModelOnce.Do(func() {
+		files, err := languageCorpus.ReadDir("signatures/languages")
+		if err != nil {
+			panic("Failed to read bundled language corpus: " + err.Error())
+		}
+		for _, file := range files {
+			data, err := languageCorpus.ReadFile("signatures/languages/" + file.Name())
+			if err != nil {
+				panic("Failed to read bundled language corpus: " + err.Error())
+			}
+			counts := trigramCounts(string(data))
+			total := 0
+			for _, c := range counts {
+				total += c
+			}
+
+			// Add one smoothing over the known trigrams plus one unseen bucket
+			denominator := float64(total + len(counts) + 1)
+			profile := languageProfile{
+				code:       strings.TrimSuffix(file.Name(), path.Ext(file.Name())),
+				logProbs:   make(map[string]float64, len(counts)),
+				unseenProb: math.Log(1 / denominator),
+			}
+			for tri, c := range counts {
+				profile.logProbs[tri] = math.Log(float64(c+1) / denominator)
+			}
+			languageProfiles = append(languageProfiles, profile)
+		}
+		sort.Slice(languageProfiles, func(i, j int) bool { return languageProfiles[i].code < languageProfiles[j].code })
+	})
+	return languageProfiles
+}
+
+// trigramCounts counts the letter trigrams of the text, words padded with spaces
+func trigramCounts(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+	return counts
+}
+
+// detectLanguage returns the most likely language code of the text with a 0-1 confidence
+func detectLanguage(text string) (string, float64) {
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters < minDetectLetters {
+		return UndeterminedLanguage, 0
+	}
+
+	counts := trigramCounts(text)
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+
+	profiles := getLanguageProfiles()
+	scores := make([]float64, len(profiles))
+	best := 0
+	for i, profile := range profiles {
+		for tri, c := range counts {
+			logProb, ok := profile.logProbs[tri]
+			if !ok {
+				logProb = profile.unseenProb
+			}
+			scores[i] += float64(c) * logProb
+		}
+		// Average per trigram so the confidence does not depend on the text length
+		scores[i] /= float64(total)
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+
+	sum := 0.0
+	for _, score := range scores {
+		sum += math.Exp(languageConfidenceScale * (score - scores[best]))
+	}
+	return profiles[best].code, round2(1 / sum)
+}
+
+// hintConfidence caps a detection confidence at what the bundled profiles can support
+func hintConfidence(confidence float64) float64 {
+	return min(confidence, maxLanguageHintConfidence)
+}
+
+// similarLanguage reports whether the profiles are known to confuse the two languages
+func similarLanguage(a, b string) bool {
+	return containsString(similarLanguages[a], b)
+}
+
+// primaryLanguage returns the primary subtag of a language tag, e.g. "en" for "en-US"
+func primaryLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
@@ -0,0 +1,97 @@
+package analyzers
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// LinkScope decides which hosts count as internal
+type LinkScope string
+
+const (
+	// Only the exact page host is internal
+	LinkScopeHost LinkScope = "host"
+	// Hosts sharing the registrable domain (eTLD+1) of the page are internal or subdomains
+	LinkScopeRegistrableDomain LinkScope = "registrable_domain"
+)
+
+// LinkClassification configures the internal, subdomain and external split of links
+type LinkClassification struct {
+	Scope LinkScope
+	// Domains treated as first-party, a domain also covers its subdomains
+	FirstPartyDomains []string
+}
+
+var (
+	linkClassificationMu      sync.RWMutex
+	defaultLinkClassification = LinkClassification{Scope: LinkScopeRegistrableDomain}
+)
+
+// ParseLinkScope validates a scope name, empty returns the registrable domain scope
+func ParseLinkScope(scope string) (LinkScope, error) {
+	switch LinkScope(strings.ToLower(strings.TrimSpace(scope))) {
+	case "", LinkScopeRegistrableDomain:
+		return LinkScopeRegistrableDomain, nil
+	case LinkScopeHost:
+		return LinkScopeHost, nil
+	}
+	return "", fmt.Errorf("unknown link scope %q, expected %q or %q", scope, LinkScopeHost, LinkScopeRegistrableDomain)
+}
+
+// SetDefaultLinkClassification replaces the classification used when a request does not choose one
+func SetDefaultLinkClassification(c LinkClassification) {
+	linkClassificationMu.Lock()
+	defer linkClassificationMu.Unlock()
+	defaultLinkClassification = c
+}
+
+// DefaultLinkClassification returns a copy of the configured classification
+func DefaultLinkClassification() LinkClassification {
+	linkClassificationMu.RLock()
+	defer linkClassificationMu.RUnlock()
+	c := defaultLinkClassification
+	c.FirstPartyDomains = append([]string(nil), c.FirstPartyDomains...)
+	return c
+}
+
+// classify determines if link is Internal, Subdomain, External or Unknown seen from pageHost
+func (c LinkClassification) classify(link string, pageHost string) LinkType {
+	parsedUrl, err := url.Parse(link)
+	if err != nil || pageHost == "" {
+		return Unknown
+	}
+	host := normalizeHost(parsedUrl.Hostname())
+	if host == "" {
+		return Unknown
+	}
+	pageHost = normalizeHost(pageHost)
+
+	if host == pageHost {
+		return Internal
+	}
+	for _, domain := range c.FirstPartyDomains {
+		if domain = normalizeHost(domain); domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return Internal
+		}
+	}
+	// Ip addresses have no registrable domain
+	if c.Scope == LinkScopeHost || net.ParseIP(host) != nil {
+		return External
+	}
+
+	if registrableDomain(host) != registrableDomain(pageHost) {
+		return External
+	}
+	// www.example.com and example.com are the same site
+	if strings.TrimPrefix(host, "www.") == strings.TrimPrefix(pageHost, "www.") {
+		return Internal
+	}
+	return Subdomain
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+}
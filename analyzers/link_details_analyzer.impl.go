@@ -2,12 +2,14 @@ package analyzers
 
 import (
 	"log"
-	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"github.com/janithT/webpage-analyzer/handler/models"
 )
 
 // LinkType indicates internal or external
@@ -17,6 +19,7 @@ const (
 	Internal LinkType = iota
 	External
 	Unknown
+	Subdomain
 )
 
 // MarshalJSON for pretty printing LinkType
@@ -26,26 +29,64 @@ func (lt LinkType) MarshalJSON() ([]byte, error) {
 		return []byte(`"Internal"`), nil
 	case External:
 		return []byte(`"External"`), nil
+	case Subdomain:
+		return []byte(`"Subdomain"`), nil
 	default:
 		return []byte(`"Unknown"`), nil
 	}
 }
 
+// Link health derived from the status code and the soft 404 check
+const (
+	LinkOk       = "ok"
+	LinkRedirect = "redirect"
+	LinkBroken   = "broken"
+	LinkSoft404  = "soft_404"
+)
+
+// Fragment anchor check results
+const (
+	AnchorOk        = "ok"
+	AnchorMissing   = "missing"
+	AnchorUnchecked = "unchecked"
+)
+
 type LinkProperty struct {
-	Url        string   `json:"url"`
-	Type       LinkType `json:"type"`
-	StatusCode int      `json:"status_code"`
-	Latency    int64    `json:"latency"` // milliseconds
+	Url          string                 `json:"url"`
+	Type         LinkType               `json:"type"`
+	StatusCode   int                    `json:"status_code"`
+	Status       string                 `json:"status"`
+	Latency      int64                  `json:"latency"` // milliseconds
+	Timing       *fetcher.RequestTiming `json:"timing,omitempty"`
+	Fragment     string                 `json:"fragment,omitempty"`
+	AnchorStatus string                 `json:"anchor_status,omitempty"`
+	Sources      []string               `json:"sources"` // element[attribute] the url was found in
+	Occurrences  int                    `json:"occurrences"`
+	FromCache    bool                   `json:"from_cache"`
+	Attempts     int                    `json:"attempts"`
+	// Why a broken link failed and the underlying error of unreachable links
+	FailureReason fetcher.FailureReason `json:"failure_reason,omitempty"`
+	Error         string                `json:"error,omitempty"`
+	// Why a 2xx link is suspected to be a soft 404
+	Soft404Evidence []string `json:"soft_404_evidence,omitempty"`
 }
 
 type linkAnalyzer struct {
-	links []LinkProperty
-	mu    sync.Mutex
+	links          []LinkProperty
+	mu             sync.Mutex
+	checker        *fetcher.LinkChecker
+	classification LinkClassification
+	mxResolver     MXResolver
 }
 
-// new linkAnalyzer instance
-func LinkAnalyzer() Analyzer {
-	return &linkAnalyzer{}
+// new linkAnalyzer instance, links are checked with the shared checker
+// and split into internal, subdomain and external by classification,
+// mailto domains are looked up with mxResolver unless it is nil
+func LinkAnalyzer(checker *fetcher.LinkChecker, classification LinkClassification, mxResolver MXResolver) Analyzer {
+	if checker == nil {
+		checker = fetcher.NewLinkChecker(10 * time.Second)
+	}
+	return &linkAnalyzer{checker: checker, classification: classification, mxResolver: mxResolver}
 }
 
 // Analyze extracts all URLs and fetches their status asynchronously
@@ -58,102 +99,168 @@ func (l *linkAnalyzer) Analyze(doc *goquery.Document, rawHTML string) Result {
 
 	l.links = nil
 
-	baseDomain := ""
+	pageHost := ""
 	if doc.Url != nil && doc.Url.Host != "" {
-		baseDomain = doc.Url.Hostname()
+		pageHost = doc.Url.Hostname()
 	}
 
-	// Temporary map to deduplicate URLs
-	linkMap := make(map[string]LinkProperty)
+	// Relative urls resolve against <base href> when the page declares one
+	base := documentBase(doc)
 
-	// Find all tags with URLs
-	doc.Find("a[href], link[href], script[src], img[src]").Each(func(i int, s *goquery.Selection) {
-		var attr string
-		if s.Is("a, link") {
-			attr, _ = s.Attr("href")
-		} else { // script, img
-			attr, _ = s.Attr("src")
-		}
-		if attr == "" {
-			return
+	// Temporary map to deduplicate URLs, in document order
+	linkMap := make(map[string]*LinkProperty)
+	var order []string
+
+	// mailto, tel, ftp, javascript and data links are inventoried, not checked
+	otherMap := make(map[string]*models.NonHttpLink)
+	var otherOrder []string
+
+	for _, ref := range extractUrlRefs(doc) {
+		if scheme := linkScheme(ref.Ref); scheme != "" {
+			other, exists := otherMap[ref.Ref]
+			if !exists {
+				inspected := inspectNonHttpLink(scheme, ref.Ref)
+				other = &inspected
+				otherMap[ref.Ref] = other
+				otherOrder = append(otherOrder, ref.Ref)
+			}
+			other.Occurrences++
+			if !containsString(other.Sources, ref.Source()) {
+				other.Sources = append(other.Sources, ref.Source())
+			}
+			continue
 		}
 
-		absUrl := resolveUrl(doc.Url, attr)
+		absUrl := resolveUrl(base, ref.Ref)
 		if absUrl == "" {
-			return
+			continue
 		}
 
 		// Check if URL is valid http or https
 		parsed, err := url.Parse(absUrl)
 		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
-			return
+			continue
 		}
 
-		// Deduplicate
-		if _, exists := linkMap[absUrl]; !exists {
-			linkMap[absUrl] = LinkProperty{
-				Url:  absUrl,
-				Type: getLinkType(absUrl, baseDomain),
+		// Deduplicate, counting every occurrence and the elements it came from
+		lp, exists := linkMap[absUrl]
+		if !exists {
+			lp = &LinkProperty{
+				Url:     absUrl,
+				Type:    l.classification.classify(absUrl, pageHost),
+				Sources: []string{},
 			}
+			linkMap[absUrl] = lp
+			order = append(order, absUrl)
+		}
+		lp.Occurrences++
+		if !containsString(lp.Sources, ref.Source()) {
+			lp.Sources = append(lp.Sources, ref.Source())
 		}
-	})
+	}
 
 	// Convert map to slice
-	for _, lp := range linkMap {
-		l.links = append(l.links, lp)
+	for _, absUrl := range order {
+		l.links = append(l.links, *linkMap[absUrl])
+	}
+
+	otherLinks := models.NonHttpLinkReport{SchemeCounts: make(map[string]int), Links: []models.NonHttpLink{}}
+	for _, ref := range otherOrder {
+		otherLinks.Links = append(otherLinks.Links, *otherMap[ref])
+		otherLinks.SchemeCounts[otherMap[ref].Scheme]++
+	}
+	otherLinks.TotalCount = len(otherLinks.Links)
+
+	// MX lookups run while the links are checked
+	mxDone := make(chan struct{})
+	go func() {
+		defer close(mxDone)
+		if l.mxResolver != nil {
+			lookupMx(l.mxResolver, otherLinks.Links)
+		}
+	}()
+
+	pageUrl := ""
+	if doc.Url != nil {
+		pageUrl = fetcher.StripFragment(doc.Url.String())
+		l.checker.SetAnalyzedPage(doc, pageUrl)
 	}
 
 	// Use WaitGroup to track async checking of URLs
 	var wg sync.WaitGroup
 	wg.Add(len(l.links))
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
 	for i := range l.links {
 		go func(idx int) {
 			defer wg.Done()
-			start := time.Now()
-			resp, err := client.Head(l.links[idx].Url)
-			if err != nil || resp == nil {
-				// Possibly try GET if HEAD fails:
-				respGet, errGet := client.Get(l.links[idx].Url)
-				if errGet != nil || respGet == nil {
-					// Mark as unreachable; status code 0
-					l.mu.Lock()
-					l.links[idx].StatusCode = 0
-					l.links[idx].Latency = int64(time.Since(start).Milliseconds())
-					l.mu.Unlock()
-					return
-				}
-				resp = respGet
-				defer resp.Body.Close()
-			} else {
-				defer resp.Body.Close()
+			status := l.checker.Check(l.links[idx].Url)
+			health := linkHealth(status.StatusCode)
+
+			// Soft 404s are looked for on same site html pages only, the page itself is checked below
+			var soft fetcher.SoftNotFound
+			linkType := l.links[idx].Type
+			if health == LinkOk && (linkType == Internal || linkType == Subdomain) && fetcher.IsHTMLContentType(status.ContentType) &&
+				fetcher.StripFragment(l.links[idx].Url) != pageUrl {
+				soft = l.checker.SoftNotFound(l.links[idx].Url)
+			}
+			if soft.Suspected {
+				health = LinkSoft404
 			}
-			latency := int64(time.Since(start).Milliseconds())
 
 			l.mu.Lock()
-			l.links[idx].StatusCode = resp.StatusCode
-			l.links[idx].Latency = latency
+			l.links[idx].StatusCode = status.StatusCode
+			l.links[idx].Status = health
+			l.links[idx].Soft404Evidence = soft.Evidence
+			l.links[idx].Latency = status.Latency
+			l.links[idx].Timing = &status.Timing
+			l.links[idx].FromCache = status.FromCache
+			l.links[idx].Attempts = status.Attempts
+			l.links[idx].FailureReason = status.Failure
+			if status.Err != nil {
+				l.links[idx].Error = status.Err.Error()
+			}
 			l.mu.Unlock()
 		}(i)
 	}
 
 	wg.Wait()
 
+	pageSoft404 := fetcher.SoftNotFound{Evidence: []string{}}
+	if pageUrl != "" {
+		pageSoft404 = l.checker.PageSoftNotFound(doc, pageUrl)
+	}
+
+	brokenAnchors := l.checkAnchors(doc)
+
+	<-mxDone
+	otherLinks.Findings = nonHttpFindings(otherLinks.Links)
+
 	// Counts
 	internalCount := 0
 	externalCount := 0
+	subdomainCount := 0
 	unknownCount := 0
+	cachedCount := 0
+	soft404Count := 0
+	failureCounts := make(map[fetcher.FailureReason]int)
 
 	for _, link := range l.links {
+		if link.FromCache {
+			cachedCount++
+		}
+		if link.Status == LinkSoft404 {
+			soft404Count++
+		}
+		if link.FailureReason != "" {
+			failureCounts[link.FailureReason]++
+		}
 		switch link.Type {
 		case Internal:
 			internalCount++
 		case External:
 			externalCount++
+		case Subdomain:
+			subdomainCount++
 		default:
 			unknownCount++
 		}
@@ -164,15 +271,100 @@ func (l *linkAnalyzer) Analyze(doc *goquery.Document, rawHTML string) Result {
 	return Result{
 		Key: "urls",
 		Value: map[string]interface{}{
-			"total_count":    totalCount,
-			"internal_count": internalCount,
-			"external_count": externalCount,
-			"unknown_count":  unknownCount,
-			"links":          l.links,
+			"total_count":     totalCount,
+			"internal_count":  internalCount,
+			"external_count":  externalCount,
+			"subdomain_count": subdomainCount,
+			"unknown_count":   unknownCount,
+			"cached_count":    cachedCount,
+			"failure_counts":  failureCounts,
+			"links":           l.links,
+
+			"broken_anchor_count": len(brokenAnchors),
+			"broken_anchors":      brokenAnchors,
+
+			"soft_404_count": soft404Count,
+			"page_soft_404":  pageSoft404,
+
+			"other_links": otherLinks,
 		},
 	}
 }
 
+// linkHealth maps a status code to ok, redirect or broken, 0 is an unreachable link
+func linkHealth(statusCode int) string {
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return LinkOk
+	case statusCode >= 300 && statusCode < 400:
+		return LinkRedirect
+	}
+	return LinkBroken
+}
+
+// checkAnchors verifies that #fragment targets exist, same page anchors against doc
+// and internal targets against the fetched target page, and returns the broken ones
+func (l *linkAnalyzer) checkAnchors(doc *goquery.Document) []LinkProperty {
+	pageUrl := ""
+	if doc.Url != nil {
+		pageUrl = fetcher.StripFragment(doc.Url.String())
+	}
+	pageAnchors := fetcher.DocumentAnchors(doc)
+
+	var wg sync.WaitGroup
+	for i := range l.links {
+		parsed, err := url.Parse(l.links[i].Url)
+		if err != nil || parsed.Fragment == "" {
+			continue
+		}
+		l.links[i].Fragment = parsed.Fragment
+
+		switch {
+		case !isAnchorFragment(parsed.Fragment):
+			l.links[i].AnchorStatus = AnchorUnchecked
+		case fetcher.StripFragment(l.links[i].Url) == pageUrl:
+			l.links[i].AnchorStatus = anchorStatus(pageAnchors, parsed.Fragment)
+		case l.links[i].Type == Internal && l.links[i].StatusCode >= 200 && l.links[i].StatusCode < 300:
+			wg.Add(1)
+			go func(idx int, fragment string) {
+				defer wg.Done()
+				status := AnchorUnchecked
+				if anchors, err := l.checker.Anchors(l.links[idx].Url); err == nil {
+					status = anchorStatus(anchors, fragment)
+				}
+				l.mu.Lock()
+				l.links[idx].AnchorStatus = status
+				l.mu.Unlock()
+			}(i, parsed.Fragment)
+		default:
+			l.links[i].AnchorStatus = AnchorUnchecked
+		}
+	}
+	wg.Wait()
+
+	broken := []LinkProperty{}
+	for _, link := range l.links {
+		if link.AnchorStatus == AnchorMissing {
+			broken = append(broken, link)
+		}
+	}
+	return broken
+}
+
+// isAnchorFragment reports whether the fragment points at an element,
+// "#top", hashbang routes and text fragments do not
+func isAnchorFragment(fragment string) bool {
+	lower := strings.ToLower(fragment)
+	return lower != "top" && !strings.HasPrefix(fragment, "!") && !strings.HasPrefix(fragment, "/") && !strings.HasPrefix(fragment, ":~:")
+}
+
+func anchorStatus(anchors map[string]bool, fragment string) string {
+	if anchors[fragment] {
+		return AnchorOk
+	}
+	return AnchorMissing
+}
+
 // resolveUrl resolves a possibly relative URL href against the base *url.URL
 func resolveUrl(base *url.URL, href string) string {
 	if base == nil {
@@ -184,19 +376,3 @@ func resolveUrl(base *url.URL, href string) string {
 	}
 	return u.String()
 }
-
-// getLinkType determines if URL is Internal, External or Unknown given baseDomain
-func getLinkType(link string, baseDomain string) LinkType {
-	parsedUrl, err := url.Parse(link)
-	if err != nil || baseDomain == "" {
-		return Unknown
-	}
-	host := parsedUrl.Hostname()
-	if host == "" {
-		return Unknown
-	}
-	if host == baseDomain {
-		return Internal
-	}
-	return External
-}
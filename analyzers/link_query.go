@@ -0,0 +1,166 @@
+package analyzers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Largest page of links a query may ask for
+const maxLinkQueryLimit = 1000
+
+// LinkQuery filters, sorts and paginates the links of an analysis, zero values keep every link
+type LinkQuery struct {
+	Status string // ok, redirect, broken or soft_404
+	Type   string // internal, external, subdomain or unknown
+	Sort   string // latency, status or url
+	Desc   bool
+	Limit  int // 0 returns every link from Offset
+	Offset int
+}
+
+// LinkPage is one page of the queried links
+type LinkPage struct {
+	Links      []LinkProperty `json:"links"`
+	Pagination Pagination     `json:"pagination"`
+}
+
+// Pagination tells where the page is in the filtered links
+type Pagination struct {
+	MatchingCount int    `json:"matching_count"`
+	Offset        int    `json:"offset"`
+	Limit         int    `json:"limit"`
+	NextCursor    string `json:"next_cursor,omitempty"`
+}
+
+var linkQueryStatuses = map[string]bool{LinkOk: true, LinkRedirect: true, LinkBroken: true, LinkSoft404: true}
+
+var linkQueryTypes = map[string]LinkType{
+	"internal":  Internal,
+	"external":  External,
+	"subdomain": Subdomain,
+	"unknown":   Unknown,
+}
+
+// ParseLinkQuery reads status, type, sort, order, limit and offset or cursor from query parameters
+func ParseLinkQuery(values url.Values) (LinkQuery, error) {
+	query := LinkQuery{
+		Status: strings.ToLower(strings.TrimSpace(values.Get("status"))),
+		Type:   strings.ToLower(strings.TrimSpace(values.Get("type"))),
+		Sort:   strings.ToLower(strings.TrimSpace(values.Get("sort"))),
+	}
+	if query.Status != "" && !linkQueryStatuses[query.Status] {
+		return query, fmt.Errorf("status must be one of ok, redirect, broken or soft_404")
+	}
+	if _, ok := linkQueryTypes[query.Type]; query.Type != "" && !ok {
+		return query, fmt.Errorf("type must be one of internal, external, subdomain or unknown")
+	}
+	switch query.Sort {
+	case "", "latency", "status", "url":
+	default:
+		return query, fmt.Errorf("sort must be one of latency, status or url")
+	}
+	switch order := strings.ToLower(values.Get("order")); order {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return query, fmt.Errorf("order must be asc or desc")
+	}
+
+	var err error
+	if query.Limit, err = nonNegativeInt(values.Get("limit"), "limit"); err != nil {
+		return query, err
+	}
+	if query.Limit > maxLinkQueryLimit {
+		return query, fmt.Errorf("limit must be at most %d", maxLinkQueryLimit)
+	}
+	if query.Offset, err = nonNegativeInt(values.Get("offset"), "offset"); err != nil {
+		return query, err
+	}
+	if cursor := values.Get("cursor"); cursor != "" {
+		if query.Offset, err = decodeLinkCursor(cursor); err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+// IsZero reports whether the query keeps every link in document order
+func (q LinkQuery) IsZero() bool {
+	return q == LinkQuery{}
+}
+
+// Apply returns the page of links matching the query, links is not modified
+func (q LinkQuery) Apply(links []LinkProperty) LinkPage {
+	matching := []LinkProperty{}
+	for _, link := range links {
+		if q.Status != "" && link.Status != q.Status {
+			continue
+		}
+		if linkType, ok := linkQueryTypes[q.Type]; ok && link.Type != linkType {
+			continue
+		}
+		matching = append(matching, link)
+	}
+
+	if q.Sort != "" {
+		sort.SliceStable(matching, func(i, j int) bool {
+			a, b := matching[i], matching[j]
+			if q.Desc {
+				a, b = b, a
+			}
+			switch q.Sort {
+			case "latency":
+				return a.Latency < b.Latency
+			case "status":
+				return a.StatusCode < b.StatusCode
+			}
+			return a.Url < b.Url
+		})
+	}
+
+	page := LinkPage{Pagination: Pagination{MatchingCount: len(matching), Offset: q.Offset, Limit: q.Limit}}
+	start := q.Offset
+	if start > len(matching) {
+		start = len(matching)
+	}
+	end := len(matching)
+	if q.Limit > 0 && start+q.Limit < end {
+		end = start + q.Limit
+		page.Pagination.NextCursor = encodeLinkCursor(end)
+	}
+	page.Links = matching[start:end]
+	return page
+}
+
+// Cursors are opaque to the client, they only carry the next offset
+func encodeLinkCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodeLinkCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		if offset, ok := strings.CutPrefix(string(raw), "offset:"); ok {
+			if n, err := strconv.Atoi(offset); err == nil && n >= 0 {
+				return n, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid cursor")
+}
+
+func nonNegativeInt(value string, name string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non negative number", name)
+	}
+	return n, nil
+}
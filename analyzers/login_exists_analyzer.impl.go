@@ -6,13 +6,6 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"golang.org/x/net/html"
-)
-
-const (
-	inputTag          = "input"
-	typeAttribute     = "type"
-	passwordAttribute = "password"
 )
 
 type loginFormAnalyzer struct{}
@@ -29,31 +22,41 @@ func (a loginFormAnalyzer) Analyze(doc *goquery.Document, rawHTML string) Result
 		log.Printf("Login exists analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
 	}(startTime)
 
-	tokenizer := html.NewTokenizer(strings.NewReader(rawHTML))
-
-	for {
-		switch tokenizer.Next() {
-		case html.StartTagToken, html.SelfClosingTagToken:
-			token := tokenizer.Token()
-			if token.Data == inputTag {
-				if attrVal := getAttr(token, typeAttribute); strings.ToLower(attrVal) == passwordAttribute {
-					// Found password field, return immediately
-					return Result{Key: "hasLoginForm", Value: true}
-				}
-			}
-		case html.ErrorToken:
-			// End of document or error
-			return Result{Key: "hasLoginForm", Value: false}
+	// Only visible forms classified as login count, signup and password change forms do not
+	for _, form := range extractForms(doc) {
+		if form.Classification == FormLogin && !form.Hidden {
+			return Result{Key: "hasLoginForm", Value: true}
 		}
 	}
+
+	// Single page apps often render the password field without a form
+	if hasFormlessPasswordField(doc) {
+		return Result{Key: "hasLoginForm", Value: true}
+	}
+
+	// SSO only logins have no password field at all
+	if len(detectSSOProviders(doc)) > 0 {
+		return Result{Key: "hasLoginForm", Value: true}
+	}
+	return Result{Key: "hasLoginForm", Value: false}
 }
 
-// callback function to get attribute value
-func getAttr(token html.Token, attrName string) string {
-	for _, attr := range token.Attr {
-		if strings.EqualFold(attr.Key, attrName) {
-			return attr.Val
+// hasFormlessPasswordField reports a visible password field outside any form, new-password fields belong to signups
+func hasFormlessPasswordField(doc *goquery.Document) bool {
+	found := false
+	doc.Find("input").EachWithBreak(func(_ int, input *goquery.Selection) bool {
+		if !strings.EqualFold(strings.TrimSpace(input.AttrOr("type", "")), "password") {
+			return true
 		}
-	}
-	return ""
+		// Fields placed with form="id" were classified with their form
+		if _, hasForm := input.Attr("form"); hasForm || input.Closest("form").Length() > 0 {
+			return true
+		}
+		if strings.Contains(strings.ToLower(input.AttrOr("autocomplete", "")), "new-password") || isHiddenForm(input) {
+			return true
+		}
+		found = true
+		return false
+	})
+	return found
 }
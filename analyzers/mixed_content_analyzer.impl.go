@@ -0,0 +1,184 @@
+package analyzers
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+// mixedContentSource describes a tag attribute that loads a sub resource
+type mixedContentSource struct {
+	selector string
+	attr     string
+	active   bool
+	srcset   bool
+}
+
+// Active content can change the page (scripts, styles, frames), passive content can not (images, media)
+var mixedContentSources = []mixedContentSource{
+	{selector: "script[src]", attr: "src", active: true},
+	{selector: "link[href]", attr: "href", active: true},
+	{selector: "iframe[src]", attr: "src", active: true},
+	{selector: "frame[src]", attr: "src", active: true},
+	{selector: "object[data]", attr: "data", active: true},
+	{selector: "embed[src]", attr: "src", active: true},
+	{selector: "img[src]", attr: "src"},
+	{selector: "img[srcset]", attr: "srcset", srcset: true},
+	{selector: "source[src]", attr: "src"},
+	{selector: "source[srcset]", attr: "srcset", srcset: true},
+	{selector: "video[src]", attr: "src"},
+	{selector: "video[poster]", attr: "poster"},
+	{selector: "audio[src]", attr: "src"},
+	{selector: "track[src]", attr: "src"},
+}
+
+// Link relations that only hint the browser and never load content into the page
+var nonLoadingLinkRels = map[string]bool{
+	"canonical":    true,
+	"alternate":    true,
+	"dns-prefetch": true,
+	"preconnect":   true,
+	"author":       true,
+	"help":         true,
+	"license":      true,
+	"next":         true,
+	"prev":         true,
+	"search":       true,
+	"shortlink":    true,
+}
+
+// Link relations that load images only
+var passiveLinkRels = map[string]bool{
+	"icon":             true,
+	"shortcut":         true,
+	"apple-touch-icon": true,
+}
+
+type mixedContentAnalyzer struct{}
+
+// Construct function to mixed content analyzer
+func MixedContentAnalyzer() Analyzer {
+	return &mixedContentAnalyzer{}
+}
+
+func (a mixedContentAnalyzer) Analyze(doc *goquery.Document, _ string) Result {
+
+	startTime := time.Now()
+	log.Println("Mixed content analyzer started")
+	defer func(start time.Time) {
+		log.Printf("Mixed content analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
+	}(startTime)
+
+	report := models.MixedContentReport{
+		Active:        []models.MixedContentItem{},
+		Passive:       []models.MixedContentItem{},
+		InsecureForms: []models.MixedContentItem{},
+	}
+
+	// Mixed content only exists on https pages
+	if doc.Url == nil || doc.Url.Scheme != "https" {
+		return Result{Key: "mixedContent", Value: report}
+	}
+	report.PageIsHTTPS = true
+
+	seen := make(map[models.MixedContentItem]bool)
+	base := documentBase(doc)
+	add := func(list *[]models.MixedContentItem, base *url.URL, ref, tag, attr string) {
+		if !isInsecureUrl(base, ref) {
+			return
+		}
+		item := models.MixedContentItem{Url: resolveUrl(base, strings.TrimSpace(ref)), Tag: tag, Attribute: attr}
+		if seen[item] {
+			return
+		}
+		seen[item] = true
+		*list = append(*list, item)
+	}
+
+	for _, source := range mixedContentSources {
+		doc.Find(source.selector).Each(func(_ int, s *goquery.Selection) {
+			tag := goquery.NodeName(s)
+			active := source.active
+			if tag == "link" {
+				loads, passive := linkLoadKind(s)
+				if !loads {
+					return
+				}
+				active = !passive
+			}
+
+			list := &report.Passive
+			if active {
+				list = &report.Active
+			}
+
+			value, _ := s.Attr(source.attr)
+			if source.srcset {
+				for _, ref := range parseSrcset(value) {
+					add(list, base, ref, tag, source.attr)
+				}
+				return
+			}
+			add(list, base, value, tag, source.attr)
+		})
+	}
+
+	// Css url() references in inline styles load images and fonts, which are passive
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		style, _ := s.Attr("style")
+		for _, ref := range parseCssUrls(style) {
+			add(&report.Passive, base, ref, goquery.NodeName(s), "style")
+		}
+	})
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		for _, ref := range parseCssUrls(s.Text()) {
+			add(&report.Passive, base, ref, "style", "url()")
+		}
+	})
+
+	// Forms submitting over http leak the entered data
+	doc.Find("form[action]").Each(func(_ int, s *goquery.Selection) {
+		action, _ := s.Attr("action")
+		add(&report.InsecureForms, base, action, "form", "action")
+	})
+
+	report.ActiveCount = len(report.Active)
+	report.PassiveCount = len(report.Passive)
+	report.InsecureFormCount = len(report.InsecureForms)
+
+	return Result{Key: "mixedContent", Value: report}
+}
+
+// isInsecureUrl reports whether ref resolves to a plain http url
+func isInsecureUrl(base *url.URL, ref string) bool {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return false
+	}
+	parsed, err := url.Parse(resolveUrl(base, ref))
+	if err != nil {
+		return false
+	}
+	return parsed.Scheme == "http"
+}
+
+// linkLoadKind reports whether a link tag loads content into the page and whether that content is passive
+func linkLoadKind(s *goquery.Selection) (loads bool, passive bool) {
+	rel, _ := s.Attr("rel")
+	rels := strings.Fields(strings.ToLower(rel))
+	passive = len(rels) > 0
+	for _, r := range rels {
+		if nonLoadingLinkRels[r] {
+			continue
+		}
+		loads = true
+		if !passiveLinkRels[r] {
+			passive = false
+		}
+	}
+	return loads, loads && passive
+}
@@ -0,0 +1,330 @@
+package analyzers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+// Schemes of the links that are inventoried instead of checked over http
+const (
+	SchemeMailto     = "mailto"
+	SchemeTel        = "tel"
+	SchemeFtp        = "ftp"
+	SchemeJavascript = "javascript"
+	SchemeData       = "data"
+)
+
+var nonHttpSchemes = map[string]bool{
+	SchemeMailto:     true,
+	SchemeTel:        true,
+	SchemeFtp:        true,
+	SchemeJavascript: true,
+	SchemeData:       true,
+}
+
+// MX lookup results of a mailto domain
+const (
+	MxOk           = "ok"
+	MxMissing      = "no_mx"
+	MxLookupFailed = "lookup_failed"
+)
+
+const (
+	// Data uris are shortened to this many characters in the report
+	maxReportedDataUriLen = 64
+	// Larger data uris bloat the html and can not be cached separately
+	largeDataUriBytes = 10 * 1024
+	// E.164 numbers have at most 15 digits, shorter than 7 are not dialable numbers
+	minE164Digits = 7
+	maxE164Digits = 15
+
+	mxLookupTimeout = 3 * time.Second
+)
+
+// MXResolver looks up mail exchangers, *net.Resolver implements it
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// linkScheme returns the lower case scheme of a raw reference if it is one of the non http schemes
+func linkScheme(ref string) string {
+	i := strings.Index(ref, ":")
+	if i <= 0 {
+		return ""
+	}
+	scheme := strings.ToLower(strings.TrimSpace(ref[:i]))
+	if nonHttpSchemes[scheme] {
+		return scheme
+	}
+	return ""
+}
+
+// inspectNonHttpLink validates a non http link by its scheme, MX lookups are done later
+func inspectNonHttpLink(scheme string, ref string) models.NonHttpLink {
+	link := models.NonHttpLink{Url: ref, Scheme: scheme, Sources: []string{}}
+	body := strings.TrimSpace(ref[strings.Index(ref, ":")+1:])
+
+	switch scheme {
+	case SchemeMailto:
+		link.Addresses, link.Issue = parseMailto(body)
+		link.Valid = link.Issue == ""
+	case SchemeTel:
+		link.Normalized, link.Issue = normalizeE164(body)
+		link.Valid = link.Issue == ""
+	case SchemeFtp:
+		parsed, err := url.Parse(ref)
+		link.Valid = err == nil && parsed.Host != ""
+		if !link.Valid {
+			link.Issue = "ftp link without a host"
+		}
+	case SchemeJavascript:
+		link.Valid = true
+		if isVoidJavascript(body) {
+			link.Issue = "javascript:void link, use a button for actions"
+		} else {
+			link.Issue = "javascript link, not reachable without scripts"
+		}
+	case SchemeData:
+		link.MediaType, link.Bytes, link.Valid = parseDataUri(body)
+		if !link.Valid {
+			link.Issue = "malformed data uri"
+		} else if link.Bytes > largeDataUriBytes {
+			link.Issue = fmt.Sprintf("large data uri (%d KB)", link.Bytes/1024)
+		}
+		if len(link.Url) > maxReportedDataUriLen {
+			link.Url = link.Url[:maxReportedDataUriLen] + "…"
+		}
+	}
+	return link
+}
+
+// parseMailto returns the addresses of a mailto body (addr1,addr2?subject=...) and an issue when one is invalid
+func parseMailto(body string) ([]string, string) {
+	to, query, _ := strings.Cut(body, "?")
+	var raw []string
+	if to != "" {
+		raw = append(raw, strings.Split(to, ",")...)
+	}
+	if values, err := url.ParseQuery(query); err == nil {
+		for _, key := range []string{"to", "cc", "bcc"} {
+			for _, value := range values[key] {
+				raw = append(raw, strings.Split(value, ",")...)
+			}
+		}
+	}
+
+	addresses := []string{}
+	for _, addr := range raw {
+		if decoded, err := url.PathUnescape(addr); err == nil {
+			addr = decoded
+		}
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil || !strings.Contains(emailDomain(parsed.Address), ".") {
+			return addresses, fmt.Sprintf("invalid email address %q", addr)
+		}
+		addresses = append(addresses, parsed.Address)
+	}
+	if len(addresses) == 0 {
+		return addresses, "mailto link without an address"
+	}
+	return addresses, ""
+}
+
+func emailDomain(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return strings.ToLower(address[i+1:])
+	}
+	return ""
+}
+
+// normalizeE164 turns a tel body into +<digits>, dropping visual separators and parameters like ;ext=
+func normalizeE164(body string) (string, string) {
+	if decoded, err := url.PathUnescape(body); err == nil {
+		body = decoded
+	}
+	number, _, _ := strings.Cut(body, ";")
+	number = strings.TrimSpace(number)
+
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+	if !strings.HasPrefix(number, "+") {
+		return "", "tel number without a +country code is not E.164"
+	}
+
+	var digits strings.Builder
+	for _, r := range number[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case strings.ContainsRune(" -.()/", r):
+		default:
+			return "", fmt.Sprintf("tel number contains %q", r)
+		}
+	}
+	if digits.Len() < minE164Digits || digits.Len() > maxE164Digits {
+		return "", fmt.Sprintf("tel number has %d digits, E.164 allows %d to %d", digits.Len(), minE164Digits, maxE164Digits)
+	}
+	if strings.HasPrefix(digits.String(), "0") {
+		return "", "E.164 country codes do not start with 0"
+	}
+	return "+" + digits.String(), ""
+}
+
+// isVoidJavascript reports whether a javascript: link only exists to run a click handler
+func isVoidJavascript(body string) bool {
+	compact := strings.ToLower(strings.Join(strings.Fields(body), ""))
+	compact = strings.TrimSuffix(compact, ";")
+	switch compact {
+	case "", "void(0)", "void0", "void(null)", "undefined", "false", "#":
+		return true
+	}
+	return false
+}
+
+// parseDataUri returns the media type and decoded size of a data uri body, [<mediatype>][;base64],<data>
+func parseDataUri(body string) (string, int, bool) {
+	meta, data, found := strings.Cut(body, ",")
+	if !found {
+		return "", 0, false
+	}
+	mediaType := "text/plain"
+	if mt, _, _ := strings.Cut(meta, ";"); strings.TrimSpace(mt) != "" {
+		mediaType = strings.ToLower(strings.TrimSpace(mt))
+	}
+
+	if strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		data = strings.Join(strings.Fields(data), "")
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			// Some pages leave out the padding
+			if decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "=")); err != nil {
+				return mediaType, 0, false
+			}
+		}
+		return mediaType, len(decoded), true
+	}
+	if decoded, err := url.PathUnescape(data); err == nil {
+		return mediaType, len(decoded), true
+	}
+	return mediaType, len(data), true
+}
+
+// lookupMx sets the MxStatus of valid mailto links, one lookup per domain
+func lookupMx(resolver MXResolver, links []models.NonHttpLink) {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, link := range links {
+		if link.Scheme != SchemeMailto || !link.Valid {
+			continue
+		}
+		for _, addr := range link.Addresses {
+			if domain := emailDomain(addr); !seen[domain] {
+				seen[domain] = true
+				domains = append(domains, domain)
+			}
+		}
+	}
+
+	statuses := make(map[string]string, len(domains))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, domain := range domains {
+		wg.Add(1)
+		go func(domain string) {
+			defer wg.Done()
+			status := mxStatus(resolver, domain)
+			mu.Lock()
+			statuses[domain] = status
+			mu.Unlock()
+		}(domain)
+	}
+	wg.Wait()
+
+	// A link is only as good as its worst address
+	for i := range links {
+		if links[i].Scheme != SchemeMailto || !links[i].Valid {
+			continue
+		}
+		links[i].MxStatus = MxOk
+		for _, addr := range links[i].Addresses {
+			if status := statuses[emailDomain(addr)]; status != MxOk {
+				links[i].MxStatus = status
+			}
+		}
+	}
+}
+
+func mxStatus(resolver MXResolver, domain string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), mxLookupTimeout)
+	defer cancel()
+
+	records, err := resolver.LookupMX(ctx, domain)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return MxMissing
+	}
+	if err != nil {
+		return MxLookupFailed
+	}
+	// A single "." record is the null MX of domains that accept no mail
+	if len(records) == 0 || (len(records) == 1 && strings.Trim(records[0].Host, ".") == "") {
+		return MxMissing
+	}
+	return MxOk
+}
+
+// nonHttpFindings reports the invalid and inaccessible non http links
+func nonHttpFindings(links []models.NonHttpLink) []models.Finding {
+	var invalidMailto, noMx, invalidTel, voidJs, largeData []string
+	for _, link := range links {
+		switch {
+		case link.Scheme == SchemeMailto && !link.Valid:
+			invalidMailto = append(invalidMailto, link.Url)
+		case link.Scheme == SchemeMailto && link.MxStatus == MxMissing:
+			noMx = append(noMx, link.Url)
+		case link.Scheme == SchemeTel && !link.Valid:
+			invalidTel = append(invalidTel, link.Url)
+		case link.Scheme == SchemeJavascript && isVoidJavascript(link.Url[len(SchemeJavascript)+1:]):
+			voidJs = append(voidJs, fmt.Sprintf("%s (%s)", link.Url, strings.Join(link.Sources, ", ")))
+		case link.Scheme == SchemeData && link.Bytes > largeDataUriBytes:
+			largeData = append(largeData, fmt.Sprintf("%s (%d KB)", link.Url, link.Bytes/1024))
+		}
+	}
+
+	findings := []models.Finding{}
+	add := func(ruleID string, severity string, message string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		sort.Strings(items)
+		findings = append(findings, models.Finding{
+			RuleID:   ruleID,
+			Severity: severity,
+			Message:  message,
+			Count:    len(items),
+			Items:    items,
+		})
+	}
+	add("link-invalid-mailto", models.SeverityWarning, "mailto links with an invalid address", invalidMailto)
+	add("link-mailto-no-mx", models.SeverityWarning, "mailto links to domains that accept no mail", noMx)
+	add("link-invalid-tel", models.SeverityInfo, "tel links that are not valid E.164 numbers", invalidTel)
+	add("link-javascript-void", models.SeverityWarning, "javascript:void links are not keyboard or screen reader friendly, use a button", voidJs)
+	add("link-large-data-uri", models.SeverityInfo, fmt.Sprintf("data uris larger than %d KB", largeDataUriBytes/1024), largeData)
+	return findings
+}
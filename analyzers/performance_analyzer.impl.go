@@ -0,0 +1,291 @@
+package analyzers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+const (
+	// Images after this many are treated as below the fold
+	aboveFoldImageCount = 3
+
+	largeHTMLBytes      = 100 * 1024
+	largePageBytes      = 2 * 1024 * 1024
+	minCompressionBytes = 1024
+	maxRequestCount     = 50
+)
+
+// Font file extensions
+var fontExtensions = map[string]bool{
+	".woff2": true,
+	".woff":  true,
+	".ttf":   true,
+	".otf":   true,
+	".eot":   true,
+}
+
+type performanceAnalyzer struct {
+	checker         *fetcher.LinkChecker
+	transferSize    int64
+	contentEncoding string
+}
+
+// Construct function to performance analyzer, transfer size and encoding come from the page response
+func PerformanceAnalyzer(checker *fetcher.LinkChecker, transferSize int64, contentEncoding string) Analyzer {
+	if checker == nil {
+		checker = fetcher.NewLinkChecker(10 * time.Second)
+	}
+	return &performanceAnalyzer{checker: checker, transferSize: transferSize, contentEncoding: contentEncoding}
+}
+
+func (a performanceAnalyzer) Analyze(doc *goquery.Document, raw string) Result {
+
+	startTime := time.Now()
+	log.Println("Performance analyzer started")
+	defer func(start time.Time) {
+		log.Printf("Performance analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
+	}(startTime)
+
+	report := models.PerformanceReport{
+		Score:           100,
+		HTMLSize:        int64(len(raw)),
+		TransferSize:    a.transferSize,
+		ContentEncoding: a.contentEncoding,
+		Resources:       make(map[string]models.ResourceWeight),
+		Findings:        []models.Finding{},
+	}
+	if report.TransferSize <= 0 {
+		report.TransferSize = report.HTMLSize
+	}
+	if report.HTMLSize > 0 {
+		report.CompressionRatio = math.Round(float64(report.TransferSize)/float64(report.HTMLSize)*100) / 100
+	}
+
+	addFinding := func(finding models.Finding) {
+		report.Score -= finding.ScoreImpact
+		report.Findings = append(report.Findings, finding)
+	}
+
+	// Resource weights from the shared link checks
+	resources := collectPerformanceResources(doc)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	requestCount := 0
+	for kind, urls := range resources {
+		requestCount += len(urls)
+		for _, u := range urls {
+			wg.Add(1)
+			go func(kind string, u string) {
+				defer wg.Done()
+				status := a.checker.Check(u)
+				mu.Lock()
+				defer mu.Unlock()
+				weight := report.Resources[kind]
+				weight.Count++
+				if status.ContentLength > 0 {
+					weight.TotalKnownBytes += status.ContentLength
+				} else {
+					weight.UnknownSize++
+				}
+				report.Resources[kind] = weight
+			}(kind, u)
+		}
+	}
+	wg.Wait()
+
+	report.TotalKnownBytes = report.TransferSize
+	for _, weight := range report.Resources {
+		report.TotalKnownBytes += weight.TotalKnownBytes
+	}
+
+	if report.HTMLSize > largeHTMLBytes {
+		addFinding(models.Finding{
+			RuleID:      "perf-large-html",
+			Severity:    models.SeverityWarning,
+			Message:     fmt.Sprintf("HTML document is %d KB, keep it under %d KB", report.HTMLSize/1024, largeHTMLBytes/1024),
+			ScoreImpact: 10,
+		})
+	}
+	if a.contentEncoding == "" && report.HTMLSize > minCompressionBytes {
+		addFinding(models.Finding{
+			RuleID:      "perf-uncompressed-html",
+			Severity:    models.SeverityWarning,
+			Message:     "HTML document is served without compression",
+			ScoreImpact: 10,
+		})
+	}
+	if report.TotalKnownBytes > largePageBytes {
+		addFinding(models.Finding{
+			RuleID:      "perf-heavy-page",
+			Severity:    models.SeverityWarning,
+			Message:     fmt.Sprintf("Known page weight is %d KB, keep it under %d KB", report.TotalKnownBytes/1024, largePageBytes/1024),
+			ScoreImpact: 15,
+		})
+	}
+	if requestCount > maxRequestCount {
+		addFinding(models.Finding{
+			RuleID:      "perf-too-many-requests",
+			Severity:    models.SeverityInfo,
+			Message:     fmt.Sprintf("Page references %d resources", requestCount),
+			Count:       requestCount,
+			ScoreImpact: 5,
+		})
+	}
+
+	if blocking := findRenderBlocking(doc); len(blocking) > 0 {
+		addFinding(models.Finding{
+			RuleID:      "perf-render-blocking",
+			Severity:    models.SeverityWarning,
+			Message:     "Scripts without async/defer and stylesheets in <head> block rendering",
+			Count:       len(blocking),
+			Items:       blocking,
+			ScoreImpact: cappedImpact(len(blocking), 5, 30),
+		})
+	}
+
+	unsized, eager := findImageIssues(doc)
+	if len(unsized) > 0 {
+		addFinding(models.Finding{
+			RuleID:      "perf-image-no-dimensions",
+			Severity:    models.SeverityInfo,
+			Message:     "Images without width and height cause layout shifts",
+			Count:       len(unsized),
+			Items:       unsized,
+			ScoreImpact: cappedImpact(len(unsized), 2, 15),
+		})
+	}
+	if len(eager) > 0 {
+		addFinding(models.Finding{
+			RuleID:      "perf-image-not-lazy",
+			Severity:    models.SeverityInfo,
+			Message:     "Below the fold images should use loading=\"lazy\"",
+			Count:       len(eager),
+			Items:       eager,
+			ScoreImpact: cappedImpact(len(eager), 2, 10),
+		})
+	}
+
+	if report.Score < 0 {
+		report.Score = 0
+	}
+
+	return Result{Key: "performance", Value: report}
+}
+
+// collectPerformanceResources groups the unique resource urls by script, stylesheet, image and font
+func collectPerformanceResources(doc *goquery.Document) map[string][]string {
+	resources := make(map[string][]string)
+	seen := make(map[string]bool)
+	base := documentBase(doc)
+	add := func(kind string, ref string) {
+		absUrl := resolveUrl(base, strings.TrimSpace(ref))
+		parsed, err := url.Parse(absUrl)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || seen[absUrl] {
+			return
+		}
+		seen[absUrl] = true
+		if fontExtensions[strings.ToLower(path.Ext(parsed.Path))] {
+			kind = "fonts"
+		}
+		resources[kind] = append(resources[kind], absUrl)
+	}
+
+	doc.Find("script[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		add("scripts", src)
+	})
+	doc.Find("link[href]").Each(func(_ int, s *goquery.Selection) {
+		rel, _ := s.Attr("rel")
+		as, _ := s.Attr("as")
+		href, _ := s.Attr("href")
+		rel = strings.ToLower(rel)
+		switch {
+		case strings.Contains(rel, "stylesheet"):
+			add("stylesheets", href)
+		case strings.Contains(rel, "preload") && strings.EqualFold(as, "font"):
+			add("fonts", href)
+		}
+	})
+	doc.Find("img[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		add("images", src)
+	})
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		for _, ref := range parseCssUrls(s.Text()) {
+			add("images", ref)
+		}
+	})
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		style, _ := s.Attr("style")
+		for _, ref := range parseCssUrls(style) {
+			add("images", ref)
+		}
+	})
+	return resources
+}
+
+// findRenderBlocking lists synchronous scripts and stylesheets for all media inside <head>
+func findRenderBlocking(doc *goquery.Document) []string {
+	var blocking []string
+	base := documentBase(doc)
+	doc.Find("head script[src]").Each(func(_ int, s *goquery.Selection) {
+		_, async := s.Attr("async")
+		_, deferred := s.Attr("defer")
+		scriptType, _ := s.Attr("type")
+		if async || deferred || strings.EqualFold(scriptType, "module") {
+			return
+		}
+		src, _ := s.Attr("src")
+		blocking = append(blocking, resolveUrl(base, src))
+	})
+	doc.Find("head link[href]").Each(func(_ int, s *goquery.Selection) {
+		rel, _ := s.Attr("rel")
+		if !strings.Contains(strings.ToLower(rel), "stylesheet") {
+			return
+		}
+		media, _ := s.Attr("media")
+		media = strings.ToLower(strings.TrimSpace(media))
+		if media != "" && media != "all" && media != "screen" {
+			return
+		}
+		href, _ := s.Attr("href")
+		blocking = append(blocking, resolveUrl(base, href))
+	})
+	return blocking
+}
+
+// findImageIssues lists images without declared dimensions and below the fold images loaded eagerly
+func findImageIssues(doc *goquery.Document) (unsized []string, eager []string) {
+	base := documentBase(doc)
+	doc.Find("body img[src]").Each(func(i int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		src = resolveUrl(base, src)
+
+		_, hasWidth := s.Attr("width")
+		_, hasHeight := s.Attr("height")
+		if !hasWidth || !hasHeight {
+			unsized = append(unsized, src)
+		}
+
+		loading, _ := s.Attr("loading")
+		if i >= aboveFoldImageCount && !strings.EqualFold(loading, "lazy") {
+			eager = append(eager, src)
+		}
+	})
+	return unsized, eager
+}
+
+// cappedImpact returns count * perItem limited to limit
+func cappedImpact(count int, perItem int, limit int) int {
+	return min(count*perItem, limit)
+}
@@ -0,0 +1,256 @@
+package analyzers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Bundled signature database in the Wappalyzer json format
+//
+//go:embed signatures/technologies.json
+var defaultTechSignatures []byte
+
+// techPattern is a compiled Wappalyzer pattern with its optional version template and confidence
+type techPattern struct {
+	raw        string
+	regex      *regexp.Regexp
+	version    string
+	confidence int
+}
+
+// TechSignature holds the compiled patterns of one technology
+type TechSignature struct {
+	Name       string
+	Categories []string
+	Website    string
+	Implies    []string
+
+	scriptSrc []techPattern
+	html      []techPattern
+	meta      map[string][]techPattern
+	headers   map[string]techPattern
+	cookies   map[string]techPattern
+	js        map[string]jsGlobal
+}
+
+// jsGlobal is a js signature, the pattern applies to the value the inline scripts assign to the global
+type jsGlobal struct {
+	pattern techPattern
+	// reference finds the global, assignment the literal assigned to it
+	reference  *regexp.Regexp
+	assignment *regexp.Regexp
+}
+
+// TechSignatures is the loaded signature database
+type TechSignatures struct {
+	Technologies []TechSignature
+}
+
+// stringOrSlice accepts both a single pattern and a list of patterns
+type stringOrSlice []string
+
+func (s *stringOrSlice) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = []string{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+type techSignatureFile struct {
+	Categories map[string]struct {
+		Name string `json:"name"`
+	} `json:"categories"`
+	Technologies map[string]struct {
+		Cats      []int                    `json:"cats"`
+		ScriptSrc stringOrSlice            `json:"scriptSrc"`
+		HTML      stringOrSlice            `json:"html"`
+		Meta      map[string]stringOrSlice `json:"meta"`
+		Headers   map[string]string        `json:"headers"`
+		Cookies   map[string]string        `json:"cookies"`
+		JS        map[string]string        `json:"js"`
+		Implies   stringOrSlice            `json:"implies"`
+		Website   string                   `json:"website"`
+	} `json:"technologies"`
+}
+
+var (
+	techSignaturesMu sync.RWMutex
+	techSignatures   *TechSignatures
+)
+
+// LoadTechSignatures reads the signature database from path, or the bundled one when path is empty
+func LoadTechSignatures(path string) (*TechSignatures, error) {
+	data := defaultTechSignatures
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("read signatures: %w", err)
+		}
+	}
+	return ParseTechSignatures(data)
+}
+
+// ParseTechSignatures compiles a Wappalyzer like json signature database
+func ParseTechSignatures(data []byte) (*TechSignatures, error) {
+	var file techSignatureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse signatures: %w", err)
+	}
+
+	sigs := &TechSignatures{}
+	for name, tech := range file.Technologies {
+		sig := TechSignature{
+			Name:    name,
+			Website: tech.Website,
+			meta:    make(map[string][]techPattern),
+			headers: make(map[string]techPattern),
+			cookies: make(map[string]techPattern),
+			js:      make(map[string]jsGlobal),
+		}
+		for _, cat := range tech.Cats {
+			if c, ok := file.Categories[strconv.Itoa(cat)]; ok {
+				sig.Categories = append(sig.Categories, c.Name)
+			}
+		}
+		for _, implied := range tech.Implies {
+			sig.Implies = append(sig.Implies, strings.SplitN(implied, `\;`, 2)[0])
+		}
+		sig.scriptSrc = compileTechPatterns(name, tech.ScriptSrc)
+		sig.html = compileTechPatterns(name, tech.HTML)
+		for key, patterns := range tech.Meta {
+			sig.meta[strings.ToLower(key)] = compileTechPatterns(name, patterns)
+		}
+		for key, pattern := range tech.Headers {
+			if p, ok := compileTechPattern(name, pattern); ok {
+				sig.headers[key] = p
+			}
+		}
+		for key, pattern := range tech.Cookies {
+			if p, ok := compileTechPattern(name, pattern); ok {
+				sig.cookies[key] = p
+			}
+		}
+		for key, pattern := range tech.JS {
+			if p, ok := compileTechPattern(name, pattern); ok {
+				sig.js[key] = compileJsGlobal(key, p)
+			}
+		}
+		sigs.Technologies = append(sigs.Technologies, sig)
+	}
+
+	// Keep a stable order for the reports
+	sort.Slice(sigs.Technologies, func(i, j int) bool {
+		return sigs.Technologies[i].Name < sigs.Technologies[j].Name
+	})
+	return sigs, nil
+}
+
+// SetTechSignatures replaces the signature database used by the tech stack analyzer
+func SetTechSignatures(sigs *TechSignatures) {
+	techSignaturesMu.Lock()
+	defer techSignaturesMu.Unlock()
+	techSignatures = sigs
+}
+
+// GetTechSignatures returns the active signature database, falling back to the bundled one
+func GetTechSignatures() *TechSignatures {
+	techSignaturesMu.RLock()
+	sigs := techSignatures
+	techSignaturesMu.RUnlock()
+	if sigs != nil {
+		return sigs
+	}
+
+	sigs, err := LoadTechSignatures("")
+	if err != nil {
+		panic("Failed to load bundled tech signatures: " + err.Error())
+	}
+	SetTechSignatures(sigs)
+	return sigs
+}
+
+func compileTechPatterns(name string, patterns []string) []techPattern {
+	var compiled []techPattern
+	for _, pattern := range patterns {
+		if p, ok := compileTechPattern(name, pattern); ok {
+			compiled = append(compiled, p)
+		}
+	}
+	return compiled
+}
+
+// compileTechPattern parses "regex\;version:\1\;confidence:50" into a techPattern
+func compileTechPattern(name string, pattern string) (techPattern, bool) {
+	parts := strings.Split(pattern, `\;`)
+	p := techPattern{raw: parts[0], confidence: 100}
+	for _, tag := range parts[1:] {
+		key, value, _ := strings.Cut(tag, ":")
+		switch key {
+		case "version":
+			p.version = value
+		case "confidence":
+			if c, err := strconv.Atoi(value); err == nil {
+				p.confidence = c
+			}
+		}
+	}
+
+	// Wappalyzer patterns are case insensitive javascript regexes, skip the ones RE2 can not compile
+	regex, err := regexp.Compile("(?i)" + parts[0])
+	if err != nil {
+		log.Printf("Skipping %s signature pattern %q: %v", name, parts[0], err)
+		return p, false
+	}
+	p.regex = regex
+	return p, true
+}
+
+// compileJsGlobal compiles the lookups of a global like "__NEXT_DATA__" or "React.version"
+func compileJsGlobal(name string, pattern techPattern) jsGlobal {
+	root := strings.SplitN(name, ".", 2)[0]
+	return jsGlobal{
+		pattern:    pattern,
+		reference:  regexp.MustCompile(`(?:^|[^\w$.])` + regexp.QuoteMeta(root) + `(?:$|[^\w$])`),
+		assignment: regexp.MustCompile(`(?:^|[^\w$.])` + regexp.QuoteMeta(name) + `\s*[=:]\s*(?:["']([^"'\n]*)["']|([\w.\-]+))`),
+	}
+}
+
+// value returns what the scripts assign to the global, an empty pattern only needs a reference to it
+func (g jsGlobal) value(scripts string) (string, bool) {
+	if g.pattern.raw == "" {
+		return "", g.reference.MatchString(scripts)
+	}
+	groups := g.assignment.FindStringSubmatch(scripts)
+	if groups == nil {
+		return "", false
+	}
+	return groups[1] + groups[2], true
+}
+
+// match applies the pattern to value and returns the resolved version
+func (p techPattern) match(value string) (bool, string) {
+	groups := p.regex.FindStringSubmatch(value)
+	if groups == nil {
+		return false, ""
+	}
+	version := p.version
+	for i := len(groups) - 1; i >= 1; i-- {
+		version = strings.ReplaceAll(version, `\`+strconv.Itoa(i), groups[i])
+	}
+	return true, strings.TrimSpace(version)
+}
@@ -0,0 +1,173 @@
+package analyzers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/handler/models"
+)
+
+type techStackAnalyzer struct {
+	header  http.Header
+	cookies []*http.Cookie
+}
+
+// Construct function to tech stack analyzer, headers and cookies come from the page response
+func TechStackAnalyzer(header http.Header, cookies []*http.Cookie) Analyzer {
+	return &techStackAnalyzer{header: header, cookies: cookies}
+}
+
+func (a techStackAnalyzer) Analyze(doc *goquery.Document, raw string) Result {
+
+	startTime := time.Now()
+	log.Println("Tech stack analyzer started")
+	defer func(start time.Time) {
+		log.Printf("Tech stack analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
+	}(startTime)
+
+	// Script urls, and inline script bodies and ids where globals are referenced
+	var scriptSrcs []string
+	var inlineScripts strings.Builder
+	doc.Find("script").Each(func(_ int, s *goquery.Selection) {
+		if src, ok := s.Attr("src"); ok {
+			scriptSrcs = append(scriptSrcs, src)
+		} else {
+			inlineScripts.WriteString(s.Text())
+		}
+		if id, ok := s.Attr("id"); ok {
+			inlineScripts.WriteString(" " + id + " ")
+		}
+	})
+	scripts := inlineScripts.String()
+
+	metas := make(map[string][]string)
+	doc.Find("meta[content]").Each(func(_ int, s *goquery.Selection) {
+		name, ok := s.Attr("name")
+		if !ok {
+			name, _ = s.Attr("property")
+		}
+		content, _ := s.Attr("content")
+		if name != "" {
+			metas[strings.ToLower(name)] = append(metas[strings.ToLower(name)], content)
+		}
+	})
+
+	cookies := make(map[string]string)
+	for _, c := range a.cookies {
+		cookies[c.Name] = c.Value
+	}
+
+	matches := make(map[string]*models.TechMatch)
+	sigs := GetTechSignatures()
+
+	for _, sig := range sigs.Technologies {
+		tm := &models.TechMatch{Name: sig.Name, Categories: sig.Categories, Website: sig.Website}
+		record := func(p techPattern, source string, value string) {
+			ok, version := p.match(value)
+			if !ok {
+				return
+			}
+			tm.Confidence += p.confidence
+			if tm.Version == "" {
+				tm.Version = version
+			}
+			tm.Evidence = append(tm.Evidence, models.TechEvidence{Source: source, Value: truncateEvidence(value)})
+		}
+
+		for _, p := range sig.scriptSrc {
+			for _, src := range scriptSrcs {
+				record(p, "scriptSrc", src)
+			}
+		}
+		for _, p := range sig.html {
+			if loc := p.regex.FindStringIndex(raw); loc != nil {
+				record(p, "html", raw[loc[0]:loc[1]])
+			}
+		}
+		for name, patterns := range sig.meta {
+			for _, p := range patterns {
+				for _, content := range metas[name] {
+					record(p, "meta:"+name, content)
+				}
+			}
+		}
+		for name, p := range sig.headers {
+			if values := a.header.Values(name); len(values) > 0 {
+				record(p, "header:"+name, strings.Join(values, ", "))
+			}
+		}
+		for name, p := range sig.cookies {
+			if value, ok := cookies[name]; ok {
+				record(p, "cookie:"+name, value)
+			}
+		}
+		for name, global := range sig.js {
+			if value, ok := global.value(scripts); ok {
+				if value == "" {
+					value = name
+				}
+				record(global.pattern, "js:"+name, value)
+			}
+		}
+
+		if tm.Confidence > 0 {
+			if tm.Confidence > 100 {
+				tm.Confidence = 100
+			}
+			matches[sig.Name] = tm
+		}
+	}
+
+	// Add implied technologies, e.g. WordPress implies PHP
+	for _, sig := range sigs.Technologies {
+		implier, ok := matches[sig.Name]
+		if !ok {
+			continue
+		}
+		for _, implied := range sig.Implies {
+			if _, exists := matches[implied]; exists {
+				continue
+			}
+			tm := &models.TechMatch{Name: implied, Confidence: implier.Confidence}
+			for _, other := range sigs.Technologies {
+				if other.Name == implied {
+					tm.Categories = other.Categories
+					tm.Website = other.Website
+				}
+			}
+			tm.Evidence = []models.TechEvidence{{Source: "implies", Value: sig.Name}}
+			matches[implied] = tm
+		}
+	}
+
+	technologies := make([]models.TechMatch, 0, len(matches))
+	for _, tm := range matches {
+		technologies = append(technologies, *tm)
+	}
+	sort.Slice(technologies, func(i, j int) bool {
+		if technologies[i].Confidence != technologies[j].Confidence {
+			return technologies[i].Confidence > technologies[j].Confidence
+		}
+		return technologies[i].Name < technologies[j].Name
+	})
+
+	return Result{Key: "technologies", Value: technologies}
+}
+
+// truncateEvidence keeps evidence values short enough for the response, cutting on a rune boundary
+func truncateEvidence(value string) string {
+	const maxLen = 120
+	if len(value) <= maxLen {
+		return value
+	}
+	cut := maxLen
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut] + "..."
+}
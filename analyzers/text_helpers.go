@@ -0,0 +1,113 @@
+package analyzers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Elements whose text is not part of the main visible content
+var skippedTextTags = map[string]bool{
+	"head":     true,
+	"nav":      true,
+	"footer":   true,
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"iframe":   true,
+	"select":   true,
+	"button":   true,
+}
+
+// Elements that break the text flow, their text is kept on a separate line
+var blockTextTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true, "figure": true,
+	"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "main": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+}
+
+// Regex for words, keeping inner apostrophes and hyphens
+var wordRegex = regexp.MustCompile(`[\p{L}\p{N}]+(?:['’-][\p{L}\p{N}]+)*`)
+
+// extractVisibleText returns the main visible text of the document, one block per line
+func extractVisibleText(doc *goquery.Document) string {
+	root := doc.Find("main, [role=main]").First()
+	if root.Length() == 0 {
+		root = doc.Find("body").First()
+	}
+	if root.Length() == 0 {
+		root = doc.Selection
+	}
+	return visibleText(root)
+}
+
+// visibleText returns the visible text of the selection, one block per line
+func visibleText(sel *goquery.Selection) string {
+	var sb strings.Builder
+	for _, node := range sel.Nodes {
+		writeVisibleText(&sb, node)
+	}
+
+	// Collapse whitespace inside lines and drop empty lines
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func writeVisibleText(sb *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		sb.WriteString(node.Data)
+		return
+	case html.ElementNode:
+		if skippedTextTags[node.Data] || isHiddenNode(node) {
+			return
+		}
+	}
+
+	block := node.Type == html.ElementNode && blockTextTags[node.Data]
+	if block {
+		sb.WriteString("\n")
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeVisibleText(sb, child)
+	}
+	if block {
+		sb.WriteString("\n")
+	}
+}
+
+// isHiddenNode reports whether an element is hidden with markup
+func isHiddenNode(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		switch attr.Key {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if attr.Val == "true" {
+				return true
+			}
+		case "style":
+			style := strings.ReplaceAll(strings.ToLower(attr.Val), " ", "")
+			if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitWords returns the words of text
+func splitWords(text string) []string {
+	return wordRegex.FindAllString(text, -1)
+}
@@ -0,0 +1,235 @@
+package analyzers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"github.com/janithT/webpage-analyzer/handler/models"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Bundled domain categories, category name to domain list
+//
+//go:embed signatures/third_party_domains.json
+var thirdPartyDomainsJSON []byte
+
+const unknownCategory = "unknown"
+
+var (
+	thirdPartyDomainsOnce sync.Once
+	thirdPartyDomains     map[string]string
+)
+
+// getThirdPartyDomains returns the bundled domain to category map
+func getThirdPartyDomains() map[string]string {
+	thirdPartyDomainsOnce.Do(func() {
+		var categories map[string][]string
+		if err := json.Unmarshal(thirdPartyDomainsJSON, &categories); err != nil {
+			panic("Failed to parse bundled third party domains: " + err.Error())
+		}
+		thirdPartyDomains = make(map[string]string)
+		for category, domains := range categories {
+			for _, domain := range domains {
+				thirdPartyDomains[domain] = category
+			}
+		}
+	})
+	return thirdPartyDomains
+}
+
+type thirdPartyAnalyzer struct {
+	checker *fetcher.LinkChecker
+}
+
+// Construct function to third party analyzer, resource sizes come from the shared checker
+func ThirdPartyAnalyzer(checker *fetcher.LinkChecker) Analyzer {
+	if checker == nil {
+		checker = fetcher.NewLinkChecker(10 * time.Second)
+	}
+	return &thirdPartyAnalyzer{checker: checker}
+}
+
+func (a thirdPartyAnalyzer) Analyze(doc *goquery.Document, _ string) Result {
+
+	startTime := time.Now()
+	log.Println("Third party analyzer started")
+	defer func(start time.Time) {
+		log.Printf("Third party analyzer completed. Duration : %v ms", time.Since(start).Milliseconds())
+	}(startTime)
+
+	report := models.ThirdPartyReport{
+		CategoryCounts: make(map[string]int),
+		Domains:        []models.ThirdPartyDomain{},
+	}
+
+	pageDomain := ""
+	if doc.Url != nil {
+		pageDomain = registrableDomain(doc.Url.Hostname())
+	}
+
+	// Collect off-site resources, keyed by url to count each request once
+	resources := make(map[string]models.ThirdPartyResource)
+	var order []string
+	base := documentBase(doc)
+	add := func(ref string, kind string) {
+		absUrl := resolveUrl(base, strings.TrimSpace(ref))
+		parsed, err := url.Parse(absUrl)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return
+		}
+		if registrableDomain(parsed.Hostname()) == pageDomain {
+			return
+		}
+		// The first reference decides the kind, a script later prefetched or shown as an image stays a script
+		if _, exists := resources[absUrl]; exists {
+			return
+		}
+		order = append(order, absUrl)
+		resources[absUrl] = models.ThirdPartyResource{Url: absUrl, Kind: kind, Bytes: -1}
+	}
+
+	collectThirdPartyResources(doc.Selection, add)
+
+	// Tracking pixels are often inside noscript, which the parser keeps as text
+	doc.Find("noscript").Each(func(_ int, s *goquery.Selection) {
+		fragment, err := goquery.NewDocumentFromReader(strings.NewReader(s.Text()))
+		if err == nil {
+			collectThirdPartyResources(fragment.Selection, add)
+		}
+	})
+
+	// Fetch the sizes concurrently
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, u := range order {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			status := a.checker.Check(u)
+			mu.Lock()
+			res := resources[u]
+			res.Bytes = status.ContentLength
+			resources[u] = res
+			mu.Unlock()
+		}(u)
+	}
+	wg.Wait()
+
+	// Group by registrable domain
+	domains := make(map[string]*models.ThirdPartyDomain)
+	for _, u := range order {
+		res := resources[u]
+		parsed, _ := url.Parse(u)
+		host := parsed.Hostname()
+		domain := registrableDomain(host)
+
+		group, exists := domains[domain]
+		if !exists {
+			group = &models.ThirdPartyDomain{
+				Domain:     domain,
+				Category:   unknownCategory,
+				KindCounts: make(map[string]int),
+			}
+			domains[domain] = group
+		}
+		if category := categorizeHost(host); group.Category == unknownCategory {
+			group.Category = category
+		}
+
+		group.RequestCount++
+		group.KindCounts[res.Kind]++
+		if res.Bytes > 0 {
+			group.TotalKnownBytes += res.Bytes
+		}
+		group.Resources = append(group.Resources, res)
+	}
+
+	for _, group := range domains {
+		report.Domains = append(report.Domains, *group)
+		report.RequestCount += group.RequestCount
+		report.TotalKnownBytes += group.TotalKnownBytes
+		report.CategoryCounts[group.Category]++
+	}
+	sort.Slice(report.Domains, func(i, j int) bool {
+		if report.Domains[i].RequestCount != report.Domains[j].RequestCount {
+			return report.Domains[i].RequestCount > report.Domains[j].RequestCount
+		}
+		return report.Domains[i].Domain < report.Domains[j].Domain
+	})
+	report.DomainCount = len(report.Domains)
+
+	return Result{Key: "thirdParties", Value: report}
+}
+
+// collectThirdPartyResources passes every script, iframe, stylesheet, pixel and image url to add
+func collectThirdPartyResources(sel *goquery.Selection, add func(ref string, kind string)) {
+	sel.Find("script[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		add(src, "script")
+	})
+	sel.Find("iframe[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		add(src, "iframe")
+	})
+	sel.Find("link[href]").Each(func(_ int, s *goquery.Selection) {
+		rel, _ := s.Attr("rel")
+		if strings.Contains(strings.ToLower(rel), "stylesheet") {
+			href, _ := s.Attr("href")
+			add(href, "stylesheet")
+		}
+	})
+	sel.Find("img[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		if isTrackingPixel(s) {
+			add(src, "pixel")
+		} else {
+			add(src, "image")
+		}
+	})
+}
+
+// isTrackingPixel reports whether an image is a tiny or hidden beacon
+func isTrackingPixel(s *goquery.Selection) bool {
+	width, _ := s.Attr("width")
+	height, _ := s.Attr("height")
+	if (width == "0" || width == "1") && (height == "0" || height == "1") {
+		return true
+	}
+	style, _ := s.Attr("style")
+	style = strings.ReplaceAll(strings.ToLower(style), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// registrableDomain returns the eTLD+1 of host, or host itself for ips and single label hosts
+func registrableDomain(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// categorizeHost finds the most specific listed domain of host, e.g. ads.linkedin.com before linkedin.com
+func categorizeHost(host string) string {
+	domains := getThirdPartyDomains()
+	host = strings.ToLower(host)
+	for {
+		if category, ok := domains[host]; ok {
+			return category
+		}
+		dot := strings.Index(host, ".")
+		if dot < 0 {
+			return unknownCategory
+		}
+		host = host[dot+1:]
+	}
+}
@@ -0,0 +1,159 @@
+package analyzers
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Regex for css url(...) references, quoted or not
+var cssUrlRegex = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+// parseSrcset returns the candidate URLs of a srcset attribute value
+func parseSrcset(srcset string) []string {
+	var urls []string
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(strings.TrimSpace(candidate))
+		if len(fields) == 0 {
+			continue
+		}
+		urls = append(urls, fields[0])
+	}
+	return urls
+}
+
+// parseCssUrls returns every url(...) reference of a css text, skipping data uris
+func parseCssUrls(css string) []string {
+	var urls []string
+	for _, match := range cssUrlRegex.FindAllStringSubmatch(css, -1) {
+		ref := strings.TrimSpace(match[1])
+		if ref == "" || strings.HasPrefix(strings.ToLower(ref), "data:") {
+			continue
+		}
+		urls = append(urls, ref)
+	}
+	return urls
+}
+
+// urlSource describes an element attribute that holds a url
+type urlSource struct {
+	selector string
+	attr     string
+	srcset   bool
+}
+
+// Every element attribute the link analyzer extracts urls from
+var urlSources = []urlSource{
+	{selector: "a[href]", attr: "href"},
+	{selector: "area[href]", attr: "href"},
+	{selector: "link[href]", attr: "href"},
+	{selector: "base[href]", attr: "href"},
+	{selector: "script[src]", attr: "src"},
+	{selector: "img[src]", attr: "src"},
+	{selector: "img[srcset]", attr: "srcset", srcset: true},
+	{selector: "iframe[src]", attr: "src"},
+	{selector: "frame[src]", attr: "src"},
+	{selector: "embed[src]", attr: "src"},
+	{selector: "object[data]", attr: "data"},
+	{selector: "source[src]", attr: "src"},
+	{selector: "source[srcset]", attr: "srcset", srcset: true},
+	{selector: "video[src]", attr: "src"},
+	{selector: "video[poster]", attr: "poster"},
+	{selector: "audio[src]", attr: "src"},
+	{selector: "track[src]", attr: "src"},
+	{selector: "form[action]", attr: "action"},
+	{selector: "button[formaction]", attr: "formaction"},
+	{selector: "input[formaction]", attr: "formaction"},
+	{selector: "blockquote[cite], q[cite], del[cite], ins[cite]", attr: "cite"},
+}
+
+// urlRef is a url reference found in the document, not yet resolved
+type urlRef struct {
+	Ref       string
+	Element   string
+	Attribute string
+}
+
+// Source returns the element and attribute of the reference, e.g. img[srcset]
+func (r urlRef) Source() string {
+	return r.Element + "[" + r.Attribute + "]"
+}
+
+// Regex for the url part of a meta refresh content, e.g. "5; url=/next"
+var metaRefreshRegex = regexp.MustCompile(`(?i)^\s*[\d.]*\s*[;,]?\s*(?:url\s*=\s*)?['"]?([^'"]*)['"]?\s*$`)
+
+// extractUrlRefs returns every url reference of the document, covering element attributes,
+// meta refresh redirects and css url() in style attributes and blocks. Refs are grouped by source
+// in the order of urlSources, then meta refresh, style attributes and style blocks, and are in
+// document order only within a group
+func extractUrlRefs(doc *goquery.Document) []urlRef {
+	var refs []urlRef
+	add := func(ref, element, attr string) {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, urlRef{Ref: ref, Element: element, Attribute: attr})
+		}
+	}
+
+	for _, source := range urlSources {
+		doc.Find(source.selector).Each(func(_ int, s *goquery.Selection) {
+			value, _ := s.Attr(source.attr)
+			if source.srcset {
+				for _, ref := range parseSrcset(value) {
+					add(ref, goquery.NodeName(s), source.attr)
+				}
+				return
+			}
+			add(value, goquery.NodeName(s), source.attr)
+		})
+	}
+
+	doc.Find("meta[http-equiv]").Each(func(_ int, s *goquery.Selection) {
+		if strings.EqualFold(strings.TrimSpace(s.AttrOr("http-equiv", "")), "refresh") {
+			add(parseMetaRefresh(s.AttrOr("content", "")), "meta", "content")
+		}
+	})
+
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		for _, ref := range parseCssUrls(s.AttrOr("style", "")) {
+			add(ref, goquery.NodeName(s), "style")
+		}
+	})
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		for _, ref := range parseCssUrls(s.Text()) {
+			add(ref, "style", "url()")
+		}
+	})
+
+	return refs
+}
+
+// parseMetaRefresh returns the target url of a meta refresh content, empty for a plain reload
+func parseMetaRefresh(content string) string {
+	match := metaRefreshRegex.FindStringSubmatch(content)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(match[1])
+}
+
+// documentBase returns the url relative references resolve against,
+// the first <base href> resolved against the page url or the page url itself
+func documentBase(doc *goquery.Document) *url.URL {
+	href, ok := doc.Find("base[href]").First().Attr("href")
+	if !ok || strings.TrimSpace(href) == "" {
+		return doc.Url
+	}
+	var base *url.URL
+	var err error
+	if doc.Url != nil {
+		base, err = doc.Url.Parse(strings.TrimSpace(href))
+	} else {
+		base, err = url.Parse(strings.TrimSpace(href))
+	}
+	if err != nil || !base.IsAbs() {
+		return doc.Url
+	}
+	return base
+}
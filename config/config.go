@@ -9,9 +9,64 @@ import (
 )
 
 type AppConfig struct {
-	LinkTimeoutInMs int `yaml:"timeoutInMilliSec"`
-	ServicePort     int `yaml:"servicePort"`
-	ThreadCount     int `yaml:"ThreadCount"`
+	LinkTimeoutInMs    int              `yaml:"timeoutInMilliSec"`
+	ServicePort        int              `yaml:"servicePort"`
+	ThreadCount        int              `yaml:"ThreadCount"`
+	TechSignaturesFile string           `yaml:"techSignaturesFile"` // empty uses the bundled signatures
+	LinkScope          string           `yaml:"linkScope"`          // host or registrable_domain
+	FirstPartyDomains  []string         `yaml:"firstPartyDomains"`  // counted as internal links
+	LinkCache          LinkCacheConfig  `yaml:"linkCache"`
+	HostLimits         HostLimitsConfig `yaml:"hostLimits"`
+	LinkRetry          LinkRetryConfig  `yaml:"linkRetry"`
+	// Opt in, refuse requests to loopback, private and link local addresses, a private proxy included
+	BlockPrivateNetworks bool `yaml:"blockPrivateNetworks"`
+	// Recent analyses kept for paging through their links, 0 disables storing
+	AnalysisStoreSize int                 `yaml:"analysisStoreSize"`
+	ClientProfile     ClientProfileConfig `yaml:"clientProfile"`
+	// Yaml map of secret names to values, read once at startup
+	SecretsFile  string              `yaml:"secretsFile"`
+	AuthProfiles []AuthProfileConfig `yaml:"authProfiles"`
+}
+
+// How the page fetch and the link checks present themselves to the audited sites, requests may override it
+type ClientProfileConfig struct {
+	UserAgent string            `yaml:"userAgent"` // desktop, mobile, android, iphone or a full User-Agent
+	Headers   map[string]string `yaml:"headers"`
+	// Cookies and credentials are only sent to credentialHosts, never to other analyzed hosts
+	Cookies           map[string]string `yaml:"cookies"`
+	BasicAuthUser     string            `yaml:"basicAuthUser"`
+	BasicAuthPassword string            `yaml:"basicAuthPassword"`
+	BearerToken       string            `yaml:"bearerToken"`
+	CredentialHosts   []string          `yaml:"credentialHosts"`
+	Proxy             string            `yaml:"proxy"` // http, https, socks5 or socks5h url, empty connects directly
+	// Accept any certificate of insecureHosts, internal hosts with self signed certificates.
+	// Requests may only ask for it with insecureSkipVerify=true when analyzing one of them
+	InsecureSkipVerify bool     `yaml:"insecureSkipVerify"`
+	InsecureHosts      []string `yaml:"insecureHosts"`
+}
+
+// Link status cache shared between analyses, a ttl of 0 does not cache that outcome
+type LinkCacheConfig struct {
+	MaxEntries          int `yaml:"maxEntries"` // 0 disables the cache
+	SuccessTtlInSec     int `yaml:"successTtlInSec"`
+	RedirectTtlInSec    int `yaml:"redirectTtlInSec"`
+	ClientErrorTtlInSec int `yaml:"clientErrorTtlInSec"`
+	ErrorTtlInSec       int `yaml:"errorTtlInSec"`
+}
+
+// Politeness per checked host, applied across all analyses, 0 means unlimited
+type HostLimitsConfig struct {
+	MaxConcurrentPerHost int     `yaml:"maxConcurrentPerHost"`
+	RequestsPerSecond    float64 `yaml:"requestsPerSecond"`
+	Burst                int     `yaml:"burst"`
+	MaxRetryAfterInSec   int     `yaml:"maxRetryAfterInSec"` // longer Retry-After values are not waited for
+}
+
+// Retries of link checks failing with a timeout, connection reset or 5xx
+type LinkRetryConfig struct {
+	MaxRetries    int `yaml:"maxRetries"`
+	BaseDelayInMs int `yaml:"baseDelayInMs"`
+	MaxDelayInMs  int `yaml:"maxDelayInMs"`
 }
 
 var (
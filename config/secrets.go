@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Authentication step an analysis can pick by name, its secrets never come from the request
+type AuthProfileConfig struct {
+	Name string `yaml:"name"`
+	// Form login, the username and password are posted as these fields
+	LoginUrl      string            `yaml:"loginUrl"`
+	UsernameField string            `yaml:"usernameField"`
+	PasswordField string            `yaml:"passwordField"`
+	CsrfField     string            `yaml:"csrfField"` // hidden input of the login page posted back
+	ExtraFields   map[string]string `yaml:"extraFields"`
+	SessionCookie string            `yaml:"sessionCookie"` // the login failed when it is not set
+	Username      SecretRef         `yaml:"username"`
+	Password      SecretRef         `yaml:"password"`
+	// Recorded cookies replayed instead of or before the login, "name=value; other=value"
+	Cookies SecretRef `yaml:"cookies"`
+	// Hosts the profile may analyze and its cookies are set on, empty is the host of loginUrl
+	Hosts []string `yaml:"hosts"`
+}
+
+// SecretRef names where a secret is read: the environment variable, or when it is not set the secrets file entry
+type SecretRef struct {
+	Env    string `yaml:"env"`
+	Secret string `yaml:"secret"`
+}
+
+// LoadSecrets reads a yaml map of secret names to values, an empty path has no secrets
+func LoadSecrets(path string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	if path == "" {
+		return secrets, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// Resolve returns the secret, empty when neither source has it
+func (r SecretRef) Resolve(secrets map[string]string) string {
+	if r.Env != "" {
+		if value, ok := os.LookupEnv(r.Env); ok {
+			return value
+		}
+	}
+	return secrets[r.Secret]
+}
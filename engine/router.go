@@ -19,11 +19,15 @@ func NewRouter() *gin.Engine {
 	// CORS
 	router.Use(middleware.SetupCORS())
 
+	// Request id of every response and log line
+	router.Use(middleware.RequestID())
+
 	// Serve Angular dist output
 	// router.Static("/", "./web/wep-page-analyzer-ng")
 
 	// API route - use api prefix later
 	router.GET("/v1/analyze", httpHandler.AnalyzeHandler)
+	router.GET("/v1/analyses/:id", httpHandler.GetAnalysisHandler)
 
 	// fallback angular
 	router.NoRoute(func(c *gin.Context) {
@@ -0,0 +1,271 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ErrAuthFailed is returned when the authentication step did not give a session
+var ErrAuthFailed = errors.New("authentication failed")
+
+// Largest login page read when looking for the csrf field
+const maxLoginPageBytes = 1024 * 1024
+
+// Replaces secrets in logs, errors and results
+const redacted = "[REDACTED]"
+
+// Shorter secrets are not redacted, replacing them would garble any text
+const minRedactedLen = 4
+
+// Shorter usernames are not redacted, names like admin or editor also appear in paths and text
+const minRedactedUsernameLen = 8
+
+// AuthStep logs in before the page fetch, the session lives in the cookie jar of the analysis client
+type AuthStep struct {
+	Name string
+	// Form login, the credentials are posted to LoginURL as UsernameField and PasswordField
+	LoginURL      string
+	UsernameField string
+	PasswordField string
+	CSRFField     string // hidden input read from the login page and posted back, empty skips it
+	ExtraFields   map[string]string
+	Username      string
+	Password      string
+	// Recorded cookies replayed to the hosts of the step, like a Cookie header "name=value; other=value"
+	Cookies string
+	// Hosts the step may be used for and its cookies are set on, empty is the host of LoginURL
+	Hosts []string
+	// The login only succeeded when it set this cookie, empty only checks the status
+	SessionCookie string
+}
+
+var (
+	authStepsMu sync.RWMutex
+	authSteps   = make(map[string]AuthStep)
+)
+
+// SetAuthSteps sets the authentication steps requests can pick by name
+func SetAuthSteps(steps []AuthStep) {
+	authStepsMu.Lock()
+	defer authStepsMu.Unlock()
+	authSteps = make(map[string]AuthStep, len(steps))
+	for _, step := range steps {
+		authSteps[step.Name] = step
+	}
+}
+
+// AuthStepByName returns the authentication step of the name
+func AuthStepByName(name string) (AuthStep, bool) {
+	authStepsMu.RLock()
+	defer authStepsMu.RUnlock()
+	step, ok := authSteps[name]
+	return step, ok
+}
+
+// Method tells how the step authenticates: login, cookies or both
+func (s AuthStep) Method() string {
+	switch {
+	case s.LoginURL != "" && s.Cookies != "":
+		return "login+cookies"
+	case s.LoginURL != "":
+		return "login"
+	}
+	return "cookies"
+}
+
+// Validate reports steps that can not authenticate
+func (s AuthStep) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("authentication step without a name")
+	}
+	if s.LoginURL == "" && s.Cookies == "" {
+		return fmt.Errorf("authentication step %s needs a login url or cookies", s.Name)
+	}
+	if s.LoginURL == "" && len(s.Hosts) == 0 {
+		return fmt.Errorf("authentication step %s needs the hosts its cookies are for", s.Name)
+	}
+	if s.LoginURL != "" {
+		if parsed, err := url.Parse(s.LoginURL); err != nil || parsed.Host == "" {
+			return fmt.Errorf("authentication step %s has an invalid login url", s.Name)
+		}
+		if s.UsernameField == "" || s.PasswordField == "" || s.Username == "" || s.Password == "" {
+			return fmt.Errorf("authentication step %s needs the username and password fields and their secrets", s.Name)
+		}
+	}
+	if s.Cookies != "" {
+		if _, err := http.ParseCookie(s.Cookies); err != nil {
+			return fmt.Errorf("authentication step %s has invalid cookies: %v", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// StepHosts returns the hosts the step is for, its Hosts or else the host of its login url
+func (s AuthStep) StepHosts() []string {
+	if len(s.Hosts) > 0 {
+		return s.Hosts
+	}
+	if parsed, err := url.Parse(s.LoginURL); err == nil && parsed.Hostname() != "" {
+		return []string{parsed.Hostname()}
+	}
+	return nil
+}
+
+// AllowsURL reports whether the step may be used for the page of pageUrl
+func (s AuthStep) AllowsURL(pageUrl string) bool {
+	page, err := url.Parse(pageUrl)
+	return err == nil && hostSet(s.StepHosts())[strings.ToLower(page.Hostname())]
+}
+
+// Authenticate replays the cookies on the hosts of the step and logs in through client, whose cookie jar keeps the session.
+// The login requests end with ctx
+func (s AuthStep) Authenticate(ctx context.Context, client *http.Client, pageUrl string) error {
+	if client.Jar == nil {
+		return fmt.Errorf("%w: the client has no cookie jar", ErrAuthFailed)
+	}
+	if !s.AllowsURL(pageUrl) {
+		return fmt.Errorf("%w: %s is not on a host of the step", ErrAuthFailed, RedactURL(pageUrl))
+	}
+	hosts := make([]*url.URL, 0, len(s.StepHosts()))
+	for host := range hostSet(s.StepHosts()) {
+		hosts = append(hosts, &url.URL{Scheme: "https", Host: host, Path: "/"})
+	}
+
+	if s.Cookies != "" {
+		cookies, err := http.ParseCookie(s.Cookies)
+		if err != nil {
+			return fmt.Errorf("%w: invalid cookies", ErrAuthFailed)
+		}
+		for _, cookie := range cookies {
+			cookie.Path = "/"
+		}
+		for _, host := range hosts {
+			client.Jar.SetCookies(host, cookies)
+		}
+	}
+
+	if s.LoginURL != "" {
+		if err := s.login(ctx, client); err != nil {
+			return fmt.Errorf("%w: %s", ErrAuthFailed, s.Redact(err.Error()))
+		}
+	}
+
+	if s.SessionCookie == "" {
+		return nil
+	}
+	for _, host := range hosts {
+		if hasCookie(client.Jar.Cookies(host), s.SessionCookie) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no %s cookie for %s", ErrAuthFailed, s.SessionCookie, strings.Join(s.StepHosts(), ", "))
+}
+
+// login posts the credentials, with the csrf token of the login page when configured
+func (s AuthStep) login(ctx context.Context, client *http.Client) error {
+	form := url.Values{}
+	for name, value := range s.ExtraFields {
+		form.Set(name, value)
+	}
+	if s.CSRFField != "" {
+		token, err := s.csrfToken(ctx, client)
+		if err != nil {
+			return err
+		}
+		form.Set(s.CSRFField, token)
+	}
+	form.Set(s.UsernameField, s.Username)
+	form.Set(s.PasswordField, s.Password)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.LoginURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxLoginPageBytes))
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("login responded %s", resp.Status)
+	}
+	return nil
+}
+
+// csrfToken reads the value of the csrf field from the login page
+func (s AuthStep) csrfToken(ctx context.Context, client *http.Client) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.LoginURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("login page responded %s", resp.Status)
+	}
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxLoginPageBytes))
+	if err != nil {
+		return "", err
+	}
+	token, ok := doc.Find("input").FilterFunction(func(_ int, input *goquery.Selection) bool {
+		return input.AttrOr("name", "") == s.CSRFField
+	}).First().Attr("value")
+	if !ok {
+		return "", fmt.Errorf("login page has no %s field", s.CSRFField)
+	}
+	return token, nil
+}
+
+// Redact replaces the password, the username and the cookie values of the step in text
+func (s AuthStep) Redact(text string) string {
+	secrets := []string{s.Password}
+	if len(s.Username) >= minRedactedUsernameLen {
+		secrets = append(secrets, s.Username)
+	}
+	if cookies, err := http.ParseCookie(s.Cookies); err == nil {
+		for _, cookie := range cookies {
+			secrets = append(secrets, cookie.Value)
+		}
+	}
+	// Longest first so a secret containing another is replaced whole
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	for _, secret := range secrets {
+		if len(secret) >= minRedactedLen {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+	return text
+}
+
+// RedactURL hides the password of a url with user info, for logs
+func RedactURL(rawUrl string) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	return parsed.Redacted()
+}
+
+func hasCookie(cookies []*http.Cookie, name string) bool {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,256 @@
+package fetcher
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// User-Agent presets, a profile may name one instead of a full User-Agent
+var userAgentPresets = map[string]string{
+	"desktop": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+	"mobile":  "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+	"android": "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+	"iphone":  "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+}
+
+// User-Agent of profiles that set none, Go's default is blocked by many sites
+const defaultUserAgentPreset = "desktop"
+
+// ClientProfile is how the page fetch and the link checks present themselves to the audited sites
+type ClientProfile struct {
+	UserAgent string      // preset name or a full User-Agent, empty uses the desktop preset
+	Headers   http.Header // sent with every request
+	// Configured cookies and credentials, only sent to the hosts of CredentialHosts
+	Cookies           map[string]string
+	BasicAuthUser     string
+	BasicAuthPassword string
+	BearerToken       string
+	CredentialHosts   []string
+	// Authorization header value and cookies of the request, only sent to the host of the analyzed page
+	TargetAuthorization string
+	TargetCookies       map[string]string
+	// http, https, socks5 or socks5h proxy url, empty connects directly
+	Proxy string
+	// Certificates are only left unverified for the hosts of InsecureHosts
+	InsecureSkipVerify bool
+	InsecureHosts      []string
+}
+
+var (
+	clientProfileMu      sync.RWMutex
+	defaultClientProfile ClientProfile
+)
+
+// SetDefaultClientProfile sets the profile the analyses use unless the request overrides it
+func SetDefaultClientProfile(profile ClientProfile) {
+	clientProfileMu.Lock()
+	defer clientProfileMu.Unlock()
+	defaultClientProfile = profile
+}
+
+// DefaultClientProfile returns a copy of the default profile, safe to modify
+func DefaultClientProfile() ClientProfile {
+	clientProfileMu.RLock()
+	defer clientProfileMu.RUnlock()
+	profile := defaultClientProfile
+	profile.Headers = make(http.Header, len(defaultClientProfile.Headers))
+	for name, values := range defaultClientProfile.Headers {
+		profile.Headers[name] = append([]string(nil), values...)
+	}
+	profile.Cookies = make(map[string]string, len(defaultClientProfile.Cookies))
+	for name, value := range defaultClientProfile.Cookies {
+		profile.Cookies[name] = value
+	}
+	profile.TargetCookies = make(map[string]string)
+	profile.CredentialHosts = append([]string(nil), defaultClientProfile.CredentialHosts...)
+	profile.InsecureHosts = append([]string(nil), defaultClientProfile.InsecureHosts...)
+	return profile
+}
+
+// ResolveUserAgent returns the User-Agent of a preset name or the value itself
+func ResolveUserAgent(userAgent string) string {
+	if userAgent == "" {
+		userAgent = defaultUserAgentPreset
+	}
+	if preset, ok := userAgentPresets[strings.ToLower(userAgent)]; ok {
+		return preset
+	}
+	return userAgent
+}
+
+// ParseProxy validates a proxy url, empty returns nil
+func ParseProxy(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	parsed, err := url.Parse(proxy)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("proxy must be a url like socks5://host:port")
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return parsed, nil
+	}
+	return nil, fmt.Errorf("proxy scheme must be http, https, socks5 or socks5h")
+}
+
+// HasCredentials reports whether responses may depend on who is asking, such results are not shared
+func (p ClientProfile) HasCredentials() bool {
+	return p.BasicAuthUser != "" || p.BearerToken != "" || len(p.Cookies) > 0 || p.TargetAuthorization != "" || len(p.TargetCookies) > 0
+}
+
+// AllowsInsecure reports whether certificates of host may be left unverified
+func (p ClientProfile) AllowsInsecure(host string) bool {
+	return hostSet(p.InsecureHosts)[strings.ToLower(host)]
+}
+
+// NewClient returns a client for one analysis of pageUrl, the page fetch and the link checks share it and its cookie jar
+func (p ClientProfile) NewClient(pageUrl string, timeout time.Duration) (*http.Client, error) {
+	proxy, err := ParseProxy(p.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	page, err := url.Parse(pageUrl)
+	if err != nil {
+		return nil, err
+	}
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, err
+	}
+	for host := range hostSet(p.CredentialHosts) {
+		jar.SetCookies(&url.URL{Scheme: "https", Host: host, Path: "/"}, newCookies(p.Cookies))
+	}
+	jar.SetCookies(page, newCookies(p.TargetCookies))
+
+	transport := &profileTransport{
+		base:            transportFor(proxy, false),
+		profile:         p,
+		userAgent:       ResolveUserAgent(p.UserAgent),
+		authHost:        strings.ToLower(page.Hostname()),
+		credentialHosts: hostSet(p.CredentialHosts),
+	}
+	if p.InsecureSkipVerify && len(p.InsecureHosts) > 0 {
+		transport.insecure = transportFor(proxy, true)
+		transport.insecureHosts = hostSet(p.InsecureHosts)
+	}
+
+	return &http.Client{
+		Timeout:       timeout,
+		Jar:           jar,
+		CheckRedirect: checkRedirect,
+		Transport:     transport,
+	}, nil
+}
+
+// profileTransport adds the profile headers and credentials to every request
+type profileTransport struct {
+	base      http.RoundTripper
+	profile   ClientProfile
+	userAgent string
+	// Host of the analyzed page, the only one getting the target authorization
+	authHost        string
+	credentialHosts map[string]bool
+	// Transport without certificate verification, only used for insecureHosts
+	insecure      http.RoundTripper
+	insecureHosts map[string]bool
+}
+
+func (t *profileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A round tripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	for name, values := range t.profile.Headers {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	host := strings.ToLower(req.URL.Hostname())
+	if req.Header.Get("Authorization") == "" {
+		switch {
+		case host == t.authHost && t.profile.TargetAuthorization != "":
+			req.Header.Set("Authorization", t.profile.TargetAuthorization)
+		case !t.credentialHosts[host]:
+		case t.profile.BearerToken != "":
+			req.Header.Set("Authorization", "Bearer "+t.profile.BearerToken)
+		case t.profile.BasicAuthUser != "":
+			req.SetBasicAuth(t.profile.BasicAuthUser, t.profile.BasicAuthPassword)
+		}
+	}
+	if t.insecureHosts[host] {
+		return t.insecure.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Transports by proxy and tls verification, reused so connections are pooled across analyses
+type transportKey struct {
+	proxy    string
+	insecure bool
+}
+
+// More distinct per request proxies than this get a transport without pooling
+const maxProfileTransports = 32
+
+var (
+	profileTransportsMu sync.Mutex
+	profileTransports   = make(map[transportKey]*http.Transport)
+)
+
+func transportFor(proxy *url.URL, insecure bool) *http.Transport {
+	if proxy == nil && !insecure {
+		return sharedTransport
+	}
+	key := transportKey{insecure: insecure}
+	if proxy != nil {
+		key.proxy = proxy.String()
+	}
+
+	profileTransportsMu.Lock()
+	defer profileTransportsMu.Unlock()
+	if transport, ok := profileTransports[key]; ok {
+		return transport
+	}
+
+	transport := newTransport()
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if len(profileTransports) >= maxProfileTransports {
+		transport.DisableKeepAlives = true
+		return transport
+	}
+	profileTransports[key] = transport
+	return transport
+}
+
+func newCookies(values map[string]string) []*http.Cookie {
+	cookies := make([]*http.Cookie, 0, len(values))
+	for name, value := range values {
+		cookies = append(cookies, &http.Cookie{Name: name, Value: value, Path: "/"})
+	}
+	return cookies
+}
+
+// hostSet returns the lower cased hosts as a set
+func hostSet(hosts []string) map[string]bool {
+	set := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			set[host] = true
+		}
+	}
+	return set
+}
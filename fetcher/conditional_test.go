@@ -0,0 +1,50 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchPageIfModified(t *testing.T) {
+	var sent http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Clone()
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 19 Oct 2026 10:00:00 GMT")
+		io.WriteString(w, "<html><title>Hello</title></html>")
+	}))
+	defer ts.Close()
+
+	client, _ := ClientProfile{}.NewClient(ts.URL, pageFetchTimeout)
+	page, status, err := FetchPageWithClient(ts.URL, client)
+	if err != nil || status != http.StatusOK || page.NotModified {
+		t.Fatalf("expected a full fetch, got %d err %v", status, err)
+	}
+	if page.Validators.ETag != `"v1"` || page.Validators.LastModified != "Mon, 19 Oct 2026 10:00:00 GMT" {
+		t.Errorf("expected the validators to be kept, got %+v", page.Validators)
+	}
+
+	page, status, err = FetchPageIfModified(context.Background(), ts.URL, client, page.Validators)
+	if err != nil || status != http.StatusNotModified || !page.NotModified || page.Doc != nil {
+		t.Fatalf("expected not modified, got %d err %v", status, err)
+	}
+	if sent.Get("If-Modified-Since") != "Mon, 19 Oct 2026 10:00:00 GMT" {
+		t.Errorf("expected If-Modified-Since to be sent, got %v", sent)
+	}
+	// The 304 left out the validators, the ones sent still hold
+	if page.Validators.ETag != `"v1"` {
+		t.Errorf("expected the sent validators, got %+v", page.Validators)
+	}
+
+	page, _, err = FetchPageIfModified(context.Background(), ts.URL, client, Validators{ETag: `"v0"`})
+	if err != nil || page.NotModified || page.Doc.Find("title").Text() != "Hello" {
+		t.Errorf("expected a changed page to be fetched, got %+v err %v", page, err)
+	}
+}
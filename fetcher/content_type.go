@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"bytes"
+	"html"
+	"mime"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// ContentKind is the kind of document a page was detected as, it decides which analyzers run
+type ContentKind string
+
+const (
+	ContentHTML  ContentKind = "html"
+	ContentXHTML ContentKind = "xhtml"
+	ContentSVG   ContentKind = "svg"
+	ContentText  ContentKind = "text"
+)
+
+const xhtmlNamespace = "http://www.w3.org/1999/xhtml"
+
+// mediaType returns the lower case media type of a Content-Type without parameters, empty when it is missing or invalid
+func mediaType(contentType string) string {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed)
+}
+
+// isDocumentContentType reports whether a declared Content-Type may be a page, worth reading the body to sniff it.
+// A missing or generic binary type is given a try, the body decides
+func isDocumentContentType(contentType string) bool {
+	if strings.TrimSpace(contentType) == "" {
+		return true
+	}
+	declared := mediaType(contentType)
+	return strings.HasPrefix(declared, "text/") || strings.Contains(declared, "html") ||
+		strings.HasSuffix(declared, "xml") || declared == "application/octet-stream"
+}
+
+// detectContent sniffs the body and weighs it against the declared Content-Type.
+// It returns the content kind and the detected mime type, ok is false for content that is not analyzed
+func detectContent(contentType string, body []byte) (kind ContentKind, detected string, ok bool) {
+	declared := mediaType(contentType)
+	sniffed := mimetype.Detect(body)
+	detected = mediaType(sniffed.String())
+
+	switch {
+	case sniffed.Is("image/svg+xml"):
+		return ContentSVG, detected, true
+	case !isTextual(sniffed):
+		// Binary content whatever the server declared, e.g. a pdf served as text/html
+		return "", detected, false
+	case declared == "application/xhtml+xml":
+		return ContentXHTML, declared, true
+	case declared == "text/plain":
+		// Browsers show text/plain as text even when it looks like markup
+		return ContentText, detected, true
+	case sniffed.Is("text/html"):
+		return ContentHTML, detected, true
+	case sniffed.Is("text/xml"):
+		if bytes.Contains(body, []byte(xhtmlNamespace)) {
+			return ContentXHTML, "application/xhtml+xml", true
+		}
+		return "", detected, false
+	case sniffed.Is("text/plain"):
+		switch declared {
+		case "text/html":
+			// A page that does not start with a tag the sniffer knows
+			return ContentHTML, declared, true
+		case "", "application/octet-stream":
+			return ContentText, detected, true
+		}
+	}
+	// json, css, scripts, feeds and other text that is not a page
+	return "", detected, false
+}
+
+// isTextual reports whether sniffed content is text, including markup
+func isTextual(sniffed *mimetype.MIME) bool {
+	for m := sniffed; m != nil; m = m.Parent() {
+		if m.Is("text/plain") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseContent parses the body of a page of the given kind, plain text becomes a preformatted body
+func parseContent(kind ContentKind, body []byte) (*goquery.Document, error) {
+	if kind == ContentText {
+		wrapped := "<html><head></head><body><pre>" + html.EscapeString(string(body)) + "</pre></body></html>"
+		return goquery.NewDocumentFromReader(strings.NewReader(wrapped))
+	}
+	return goquery.NewDocumentFromReader(bytes.NewReader(body))
+}
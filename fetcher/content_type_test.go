@@ -0,0 +1,70 @@
+package fetcher
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var pngHeader = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00"
+
+func TestDetectContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		kind        ContentKind
+		detected    string
+		ok          bool
+	}{
+		{"html", "text/html; charset=utf-8", "<!DOCTYPE html><html><title>Hi</title></html>", ContentHTML, "text/html", true},
+		{"html without a type", "", "<html><body>Hi</body></html>", ContentHTML, "text/html", true},
+		{"html starting with text", "text/html", "Hello <b>there</b>", ContentHTML, "text/html", true},
+		{"declared xhtml", "application/xhtml+xml", `<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"></html>`, ContentXHTML, "application/xhtml+xml", true},
+		{"sniffed xhtml", "text/xml", `<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"></html>`, ContentXHTML, "application/xhtml+xml", true},
+		{"svg", "image/svg+xml", `<svg xmlns="http://www.w3.org/2000/svg"><title>Logo</title></svg>`, ContentSVG, "image/svg+xml", true},
+		{"plain text", "text/plain", "Just some words.", ContentText, "text/plain", true},
+		{"markup served as text", "text/plain", "<html><body>Hi</body></html>", ContentText, "text/html", true},
+		{"png served as html", "text/html", pngHeader, "", "image/png", false},
+		{"pdf without a type", "", "%PDF-1.4\n%âãÏÓ\n", "", "application/pdf", false},
+		{"json", "application/octet-stream", `{"title": "not a page"}`, "", "application/json", false},
+		{"rss", "text/xml", `<?xml version="1.0"?><rss version="2.0"><channel></channel></rss>`, "", "application/rss+xml", false},
+		{"css", "text/css", "body { color: red; }", "", "text/plain", false},
+	}
+	for _, tt := range tests {
+		kind, detected, ok := detectContent(tt.contentType, []byte(tt.body))
+		if kind != tt.kind || detected != tt.detected || ok != tt.ok {
+			t.Errorf("%s: expected %q %q %v, got %q %q %v", tt.name, tt.kind, tt.detected, tt.ok, kind, detected, ok)
+		}
+	}
+}
+
+func TestFetchPagePlainText(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, "Release notes <script>alert(1)</script>")
+	}))
+	defer ts.Close()
+
+	page, _, err := FetchPage(ts.URL)
+	if err != nil {
+		t.Fatalf("expected plain text to be fetched, got %v", err)
+	}
+	if page.ContentKind != ContentText || page.MimeType != "text/plain" {
+		t.Errorf("expected a text page, got %q %q", page.ContentKind, page.MimeType)
+	}
+	if page.Doc.Find("script").Length() != 0 || page.Doc.Find("pre").Text() != "Release notes <script>alert(1)</script>" {
+		t.Errorf("expected the text to be kept as text, got %q", page.Doc.Find("body").Text())
+	}
+}
+
+func TestFetchPageSniffsMislabelledContent(t *testing.T) {
+	fetchErr, status := fetchErrorKind(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, pngHeader)
+	})
+	if fetchErr.Kind != FetchUnsupportedContentType || fetchErr.DetectedType != "image/png" || status != http.StatusUnprocessableEntity {
+		t.Errorf("expected the png to be rejected, got %+v with status %d", fetchErr, status)
+	}
+}
@@ -0,0 +1,74 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// FetchErrorKind groups page fetch failures the way the API reports them
+type FetchErrorKind string
+
+const (
+	FetchInvalidURL             FetchErrorKind = "invalid_url"
+	FetchDNSFailure             FetchErrorKind = "dns_failure"
+	FetchTimeout                FetchErrorKind = "timeout"
+	FetchUpstreamClientError    FetchErrorKind = "upstream_4xx"
+	FetchUpstreamServerError    FetchErrorKind = "upstream_5xx"
+	FetchUnreachable            FetchErrorKind = "unreachable"
+	FetchBlockedByPolicy        FetchErrorKind = "blocked_by_policy"
+	FetchPageTooLarge           FetchErrorKind = "page_too_large"
+	FetchUnsupportedContentType FetchErrorKind = "unsupported_content_type"
+	FetchInvalidContent         FetchErrorKind = "invalid_content"
+)
+
+// FetchError is the typed error of FetchPage
+type FetchError struct {
+	Kind FetchErrorKind
+	// Detailed reason of transport failures, see ClassifyFailure
+	Reason FailureReason
+	// Upstream response status, 0 when there was no response
+	StatusCode int
+	// Declared and sniffed types of unsupported content
+	ContentType  string
+	DetectedType string
+	Err          error
+}
+
+func (e *FetchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// newTransportError types an error of sending the request
+func newTransportError(err error) *FetchError {
+	reason := ClassifyFailure(err)
+	kind := FetchUnreachable
+	switch reason {
+	case FailureDNSNotFound, FailureDNSError:
+		kind = FetchDNSFailure
+	case FailureTimeout:
+		kind = FetchTimeout
+	case FailureBlockedByPolicy:
+		kind = FetchBlockedByPolicy
+	case FailureInvalidUrl:
+		kind = FetchInvalidURL
+	}
+	return &FetchError{Kind: kind, Reason: reason, Err: err}
+}
+
+// newStatusError types an upstream error status
+func newStatusError(resp *http.Response) *FetchError {
+	kind := FetchUpstreamClientError
+	if resp.StatusCode >= 500 {
+		kind = FetchUpstreamServerError
+	}
+	return &FetchError{
+		Kind:       kind,
+		Reason:     FailureForStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		Err:        fmt.Errorf("upstream responded %s", resp.Status),
+	}
+}
@@ -0,0 +1,68 @@
+package fetcher
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func fetchErrorKind(t *testing.T, handler http.HandlerFunc) (*FetchError, int) {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	_, status, err := FetchPage(ts.URL)
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) {
+		t.Fatalf("expected a FetchError, got %v", err)
+	}
+	return fetchErr, status
+}
+
+func TestFetchPageErrorKinds(t *testing.T) {
+	fetchErr, status := fetchErrorKind(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	})
+	if fetchErr.Kind != FetchUpstreamClientError || fetchErr.StatusCode != http.StatusGone || status != http.StatusGone {
+		t.Errorf("expected an upstream 4xx of 410, got %+v with status %d", fetchErr, status)
+	}
+
+	fetchErr, _ = fetchErrorKind(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	if fetchErr.Kind != FetchUpstreamServerError || fetchErr.Reason != FailureHTTPServerError {
+		t.Errorf("expected an upstream 5xx, got %+v", fetchErr)
+	}
+
+	fetchErr, status = fetchErrorKind(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		io.WriteString(w, "%PDF-1.4")
+	})
+	if fetchErr.Kind != FetchUnsupportedContentType || fetchErr.ContentType != "application/pdf" || status != http.StatusUnprocessableEntity {
+		t.Errorf("expected an unsupported content type, got %+v with status %d", fetchErr, status)
+	}
+
+	fetchErr, _ = fetchErrorKind(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><body>")
+		io.WriteString(w, strings.Repeat("a", MaxPageBytes))
+	})
+	if fetchErr.Kind != FetchPageTooLarge {
+		t.Errorf("expected a too large page, got %+v", fetchErr)
+	}
+}
+
+func TestFetchPageBlockedByPolicy(t *testing.T) {
+	SetBlockPrivateNetworks(true)
+	defer SetBlockPrivateNetworks(false)
+
+	fetchErr, _ := fetchErrorKind(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html></html>")
+	})
+	if fetchErr.Kind != FetchBlockedByPolicy || fetchErr.Reason != FailureBlockedByPolicy {
+		t.Errorf("expected the loopback server to be blocked, got %+v", fetchErr)
+	}
+}
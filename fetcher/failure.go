@@ -0,0 +1,96 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// FailureReason tells why a link check failed
+type FailureReason string
+
+const (
+	FailureDNSNotFound       FailureReason = "dns_not_found"
+	FailureDNSError          FailureReason = "dns_error"
+	FailureConnectionRefused FailureReason = "connection_refused"
+	FailureConnectionReset   FailureReason = "connection_reset"
+	FailureHostUnreachable   FailureReason = "host_unreachable"
+	FailureTimeout           FailureReason = "timeout"
+	FailureTLSInvalidCert    FailureReason = "tls_invalid_cert"
+	FailureTLSHandshake      FailureReason = "tls_handshake_failed"
+	FailureRedirectLoop      FailureReason = "redirect_loop"
+	FailureTooManyRedirects  FailureReason = "too_many_redirects"
+	FailureBlockedByPolicy   FailureReason = "blocked_by_policy"
+	FailureInvalidUrl        FailureReason = "invalid_url"
+	FailureCanceled          FailureReason = "canceled"
+	FailureNetwork           FailureReason = "network_error"
+	FailureHTTPClientError   FailureReason = "http_client_error"
+	FailureHTTPServerError   FailureReason = "http_server_error"
+)
+
+// ClassifyFailure maps a request error to its reason, empty for nil
+func ClassifyFailure(err error) FailureReason {
+	if err == nil {
+		return ""
+	}
+
+	var dnsErr *net.DNSError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var verifyErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var netErr net.Error
+	var urlErr *url.Error
+
+	switch {
+	case errors.Is(err, ErrBlockedByPolicy):
+		return FailureBlockedByPolicy
+	case errors.Is(err, ErrRedirectLoop):
+		return FailureRedirectLoop
+	case errors.Is(err, ErrTooManyRedirects):
+		return FailureTooManyRedirects
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsNotFound {
+			return FailureDNSNotFound
+		}
+		if dnsErr.IsTimeout {
+			return FailureTimeout
+		}
+		return FailureDNSError
+	case errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr), errors.As(err, &invalidCert), errors.As(err, &verifyErr):
+		return FailureTLSInvalidCert
+	case errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return FailureTLSHandshake
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FailureConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return FailureConnectionReset
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return FailureHostUnreachable
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return FailureTimeout
+	case errors.Is(err, context.Canceled):
+		return FailureCanceled
+	case errors.As(err, &urlErr) && urlErr.Op == "parse":
+		return FailureInvalidUrl
+	}
+	return FailureNetwork
+}
+
+// FailureForStatus returns the reason of an http error status, empty below 400
+func FailureForStatus(statusCode int) FailureReason {
+	switch {
+	case statusCode >= 500:
+		return FailureHTTPServerError
+	case statusCode >= 400:
+		return FailureHTTPClientError
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	cases := map[FailureReason]error{
+		FailureDNSNotFound:     &net.DNSError{Err: "no such host", Name: "nope.invalid", IsNotFound: true},
+		FailureDNSError:        &net.DNSError{Err: "server misbehaving", Name: "example.com"},
+		FailureBlockedByPolicy: fmt.Errorf("dial: %w", ErrBlockedByPolicy),
+		FailureNetwork:         errors.New("something else"),
+		"":                     nil,
+	}
+	for expected, err := range cases {
+		if reason := ClassifyFailure(err); reason != expected {
+			t.Errorf("%v: expected %q, got %q", err, expected, reason)
+		}
+	}
+}
+
+// test real failures of the link checker get their reason
+func TestLinkCheckerFailureReasons(t *testing.T) {
+	loop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/a", http.StatusFound)
+	}))
+	defer loop.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	// A closed listener refuses connections
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	refused := "http://" + listener.Addr().String()
+	listener.Close()
+
+	cases := map[string]FailureReason{
+		loop.URL + "/a":  FailureRedirectLoop,
+		slow.URL:         FailureTimeout,
+		tlsServer.URL:    FailureTLSInvalidCert,
+		refused:          FailureConnectionRefused,
+		missing.URL:      FailureHTTPClientError,
+		"http://%zz/bad": FailureInvalidUrl,
+	}
+	checker := NewLinkChecker(100 * time.Millisecond)
+	for url, expected := range cases {
+		status := checker.Check(url)
+		if status.Failure != expected {
+			t.Errorf("%s: expected %q, got %q (%v)", url, expected, status.Failure, status.Err)
+		}
+	}
+}
+
+// test the policy refuses private addresses
+func TestBlockPrivateNetworks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	SetBlockPrivateNetworks(true)
+	defer SetBlockPrivateNetworks(false)
+
+	status := NewLinkChecker(time.Second).Check(ts.URL)
+	if status.Failure != FailureBlockedByPolicy {
+		t.Errorf("expected blocked_by_policy, got %q (%v)", status.Failure, status.Err)
+	}
+}
@@ -1,8 +1,9 @@
 package fetcher
 
 import (
-	"bytes"
-	"errors"
+	"compress/gzip"
+	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -13,9 +14,41 @@ import (
 	"github.com/PuerkitoBio/goquery"
 )
 
+// Largest analyzed page, after decompression
+const MaxPageBytes = 10 * 1024 * 1024
+
 // Regex url validator
 var urlRegex = regexp.MustCompile(`^(https?:\/\/)?([a-zA-Z0-9\-]+\.)+[a-zA-Z]{2,}(:\d+)?(\/[^\s]*)?$`)
 
+// Page holds the parsed document together with the response metadata
+type Page struct {
+	Doc             *goquery.Document
+	Raw             string
+	StatusCode      int
+	Header          http.Header
+	Cookies         []*http.Cookie
+	TransferSize    int64  // body bytes received over the wire
+	ContentEncoding string // e.g. gzip, empty when uncompressed
+	Timing          RequestTiming
+	ContentType     string      // declared by the server
+	MimeType        string      // detected from the body and the declared type
+	ContentKind     ContentKind // decides which analyzers run
+	// Validators for a later conditional fetch, NotModified pages have no document
+	Validators  Validators
+	NotModified bool
+}
+
+// Validators of a fetched page, sent back as If-None-Match and If-Modified-Since
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// IsZero reports whether there is nothing to revalidate with
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
 // Check url is valied
 func IsValidURL(uri string) bool {
 	parsed, err := url.ParseRequestURI(uri)
@@ -30,29 +63,166 @@ func IsRegexValidURL(uri string) bool {
 
 // Fetch and parse the url
 func FetchAndParse(uri string) (*goquery.Document, string, int, error) {
+	page, status, err := FetchPage(uri)
+	if err != nil {
+		return nil, "", status, err
+	}
+	return page.Doc, page.Raw, status, nil
+}
+
+// Timeout of the page fetch
+const pageFetchTimeout = 10 * time.Second
 
+// Fetch the url with the default client profile and return the parsed page with its response metadata
+func FetchPage(uri string) (*Page, int, error) {
+	client, err := DefaultClientProfile().NewClient(uri, pageFetchTimeout)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return FetchPageWithClient(uri, client)
+}
+
+// FetchPageWithClient fetches the url through client, see ClientProfile.NewClient
+func FetchPageWithClient(uri string, client *http.Client) (*Page, int, error) {
+	return FetchPageIfModified(context.Background(), uri, client, Validators{})
+}
+
+// FetchPageIfModified fetches the url unless it still matches the validators of an earlier fetch,
+// then it returns a NotModified page without a document and status 304. The fetch ends with ctx
+func FetchPageIfModified(ctx context.Context, uri string, client *http.Client, since Validators) (*Page, int, error) {
 	parsedURL, err := url.Parse(uri)
-	client := http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(uri)
 	if err != nil {
-		return nil, "", http.StatusBadGateway, err
+		return nil, http.StatusBadRequest, &FetchError{Kind: FetchInvalidURL, Reason: FailureInvalidUrl, Err: err}
+	}
+
+	// Ask for gzip ourselves so the compressed transfer size stays visible
+	tracer, ctx := newRequestTracer(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, http.StatusBadRequest, &FetchError{Kind: FetchInvalidURL, Reason: FailureInvalidUrl, Err: err}
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	if since.ETag != "" {
+		req.Header.Set("If-None-Match", since.ETag)
+	}
+	if since.LastModified != "" {
+		req.Header.Set("If-Modified-Since", since.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, http.StatusBadGateway, newTransportError(err)
 	}
 	defer resp.Body.Close()
 
+	validators := Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	if resp.StatusCode == http.StatusNotModified && !since.IsZero() {
+		// A 304 may leave out the validators, the ones sent still hold
+		if validators.IsZero() {
+			validators = since
+		}
+		return &Page{
+			StatusCode:  resp.StatusCode,
+			Header:      resp.Header,
+			Timing:      tracer.finish(resp.Proto),
+			Validators:  validators,
+			NotModified: true,
+		}, http.StatusNotModified, nil
+	}
+
 	if resp.StatusCode >= 400 {
-		return nil, "", resp.StatusCode, errors.New(resp.Status)
+		return nil, resp.StatusCode, newStatusError(resp)
+	}
+	// Declared media, images and documents are not downloaded to be sniffed
+	if contentType := resp.Header.Get("Content-Type"); !isDocumentContentType(contentType) {
+		return nil, http.StatusUnprocessableEntity, unsupportedContent(resp.StatusCode, contentType, "")
+	}
+	if resp.ContentLength > MaxPageBytes {
+		return nil, http.StatusUnprocessableEntity, pageTooLarge(resp.ContentLength)
 	}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	wire := &countingReader{reader: resp.Body}
+	var body io.Reader = wire
+	encoding := strings.ToLower(resp.Header.Get("Content-Encoding"))
+	if encoding == "gzip" {
+		gz, err := gzip.NewReader(wire)
+		if err != nil {
+			return nil, http.StatusBadGateway, &FetchError{Kind: FetchInvalidContent, StatusCode: resp.StatusCode, Err: err}
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	// Read one byte past the limit to tell a page of exactly MaxPageBytes from a larger one
+	bodyBytes, err := io.ReadAll(io.LimitReader(body, MaxPageBytes+1))
 	if err != nil {
-		return nil, "", http.StatusInternalServerError, err
+		return nil, http.StatusBadGateway, newTransportError(err)
+	}
+	if int64(len(bodyBytes)) > MaxPageBytes {
+		return nil, http.StatusUnprocessableEntity, pageTooLarge(-1)
+	}
+	timing := tracer.finish(resp.Proto)
+
+	contentType := resp.Header.Get("Content-Type")
+	kind, detected, ok := detectContent(contentType, bodyBytes)
+	if !ok {
+		return nil, http.StatusUnprocessableEntity, unsupportedContent(resp.StatusCode, contentType, detected)
 	}
-	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(bodyBytes))
+	doc, err := parseContent(kind, bodyBytes)
 	if err != nil {
-		return nil, "", http.StatusInternalServerError, err
+		return nil, http.StatusUnprocessableEntity, &FetchError{Kind: FetchInvalidContent, StatusCode: resp.StatusCode, Err: err}
 	}
 
 	doc.Url = parsedURL
 
-	return doc, string(bodyBytes), 200, nil
+	return &Page{
+		Doc:             doc,
+		Raw:             string(bodyBytes),
+		StatusCode:      resp.StatusCode,
+		Header:          resp.Header,
+		Cookies:         resp.Cookies(),
+		TransferSize:    wire.count,
+		ContentEncoding: encoding,
+		Timing:          timing,
+		ContentType:     contentType,
+		MimeType:        detected,
+		ContentKind:     kind,
+		Validators:      validators,
+	}, 200, nil
+}
+
+// unsupportedContent reports content that is not analyzed, detected is empty when the body was not read
+func unsupportedContent(statusCode int, contentType string, detected string) *FetchError {
+	described := detected
+	if described == "" {
+		described = mediaType(contentType)
+	}
+	return &FetchError{
+		Kind:         FetchUnsupportedContentType,
+		StatusCode:   statusCode,
+		ContentType:  contentType,
+		DetectedType: detected,
+		Err:          fmt.Errorf("content of type %q is not a web page", described),
+	}
+}
+
+// pageTooLarge reports a page over MaxPageBytes, size is -1 when only the read showed it
+func pageTooLarge(size int64) *FetchError {
+	err := fmt.Errorf("page is larger than %d bytes", MaxPageBytes)
+	if size >= 0 {
+		err = fmt.Errorf("page is %d bytes, larger than %d", size, MaxPageBytes)
+	}
+	return &FetchError{Kind: FetchPageTooLarge, Err: err}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.count += int64(n)
+	return n, err
 }
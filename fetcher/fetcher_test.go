@@ -1,6 +1,7 @@
 package fetcher
 
 import (
+	"compress/gzip"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -43,3 +44,29 @@ func TestFetchAndParseBadStatus(t *testing.T) {
 		t.Errorf("Expected NotFound error, got %d err %v", status, err)
 	}
 }
+
+// test the fetch reports the compressed transfer size
+func TestFetchPageGzip(t *testing.T) {
+	html := `<html><title>Hello</title><body>` + strings.Repeat("<p>compress me</p>", 200) + `</body></html>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("expected gzip to be accepted")
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		io.WriteString(gz, html)
+		gz.Close()
+	}))
+	defer ts.Close()
+
+	page, status, err := FetchPage(ts.URL)
+	if err != nil || status != 200 {
+		t.Fatalf("Expected success, got status %d err %v", status, err)
+	}
+	if page.Raw != html {
+		t.Errorf("Raw HTML not decompressed")
+	}
+	if page.ContentEncoding != "gzip" || page.TransferSize <= 0 || page.TransferSize >= int64(len(html)) {
+		t.Errorf("Unexpected transfer size %d for %d bytes, encoding %q", page.TransferSize, len(html), page.ContentEncoding)
+	}
+}
@@ -0,0 +1,230 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimits configures the politeness towards each checked host, zero values mean unlimited
+type HostLimits struct {
+	MaxConcurrent     int     // requests in flight per host
+	RequestsPerSecond float64 // token bucket refill rate per host
+	Burst             int     // token bucket size, at least 1
+	// Longest Retry-After a 429 or 503 is retried after, longer ones are reported as is
+	MaxRetryAfter time.Duration
+}
+
+// HostLimiter caps the concurrency and request rate per host, shared by all analyses of the process
+type HostLimiter struct {
+	limits HostLimits
+	mu     sync.Mutex
+	hosts  map[string]*hostState
+	now    func() time.Time
+	// Last time idle hosts were evicted
+	swept time.Time
+}
+
+type hostState struct {
+	slots       chan struct{} // nil without a concurrency cap
+	tokens      float64
+	refilled    time.Time
+	pausedUntil time.Time // set from Retry-After
+	lastUsed    time.Time
+}
+
+// Hosts unused for this long are forgotten, so a long running process does not keep every host it ever checked
+const hostIdleTimeout = 10 * time.Minute
+
+var (
+	sharedHostLimiterMu sync.RWMutex
+	sharedHostLimiter   *HostLimiter
+)
+
+// NewHostLimiter returns a limiter applying limits to every host separately
+func NewHostLimiter(limits HostLimits) *HostLimiter {
+	if limits.Burst < 1 {
+		limits.Burst = 1
+	}
+	return &HostLimiter{
+		limits: limits,
+		hosts:  make(map[string]*hostState),
+		now:    time.Now,
+	}
+}
+
+// SetSharedHostLimiter sets the limiter the analyses share, nil disables limiting
+func SetSharedHostLimiter(limiter *HostLimiter) {
+	sharedHostLimiterMu.Lock()
+	defer sharedHostLimiterMu.Unlock()
+	sharedHostLimiter = limiter
+}
+
+// SharedHostLimiter returns the limiter the analyses share, nil when limiting is disabled
+func SharedHostLimiter() *HostLimiter {
+	sharedHostLimiterMu.RLock()
+	defer sharedHostLimiterMu.RUnlock()
+	return sharedHostLimiter
+}
+
+func (l *HostLimiter) host(host string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	state, ok := l.hosts[host]
+	if !ok {
+		l.evictIdle(now)
+		state = &hostState{tokens: float64(l.limits.Burst), refilled: now}
+		if l.limits.MaxConcurrent > 0 {
+			state.slots = make(chan struct{}, l.limits.MaxConcurrent)
+		}
+		l.hosts[host] = state
+	}
+	state.lastUsed = now
+	return state
+}
+
+// evictIdle forgets the hosts without requests in flight, pause or use for hostIdleTimeout, at most once per timeout.
+// l.mu must be held
+func (l *HostLimiter) evictIdle(now time.Time) {
+	if now.Sub(l.swept) < hostIdleTimeout {
+		return
+	}
+	l.swept = now
+	for host, state := range l.hosts {
+		if len(state.slots) == 0 && now.After(state.pausedUntil) && now.Sub(state.lastUsed) >= hostIdleTimeout {
+			delete(l.hosts, host)
+		}
+	}
+}
+
+// Acquire waits for a token and then a free slot of host, release must be called when the request is done.
+// The token comes first so waiting out the rate or a pause does not hold a slot other requests could use
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (release func(), err error) {
+	state := l.host(strings.ToLower(host))
+
+	for {
+		wait := l.take(state)
+		if wait <= 0 {
+			break
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	if state.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case state.slots <- struct{}{}:
+		return func() { <-state.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// take consumes a token of state, or returns how long to wait for one
+func (l *HostLimiter) take(state *hostState) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(state.pausedUntil) {
+		return state.pausedUntil.Sub(now)
+	}
+	if l.limits.RequestsPerSecond <= 0 {
+		return 0
+	}
+
+	state.tokens += now.Sub(state.refilled).Seconds() * l.limits.RequestsPerSecond
+	if state.tokens > float64(l.limits.Burst) {
+		state.tokens = float64(l.limits.Burst)
+	}
+	state.refilled = now
+	if state.tokens >= 1 {
+		state.tokens--
+		return 0
+	}
+	return time.Duration((1 - state.tokens) / l.limits.RequestsPerSecond * float64(time.Second))
+}
+
+// PauseUntil holds back every request to host until the given time, callers cap it at MaxRetryAfter
+func (l *HostLimiter) PauseUntil(host string, until time.Time) {
+	state := l.host(strings.ToLower(host))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(state.pausedUntil) {
+		state.pausedUntil = until
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an http date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// limitedTransport waits for the limits of the host before each request, the client sends
+// every redirect hop through it. Waiting ends with the request context, which carries the client timeout
+type limitedTransport struct {
+	base    http.RoundTripper // nil means http.DefaultTransport
+	limiter *HostLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	release, err := t.limiter.Acquire(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	excludeWait(req.Context(), time.Since(start))
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnClose frees the host slot once the response body is closed
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
@@ -0,0 +1,213 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// test no more than MaxConcurrent requests reach one host at once
+func TestHostLimiterConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	checker := NewLinkChecker(5 * time.Second).WithHostLimiter(NewHostLimiter(HostLimits{MaxConcurrent: 2}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if status := checker.Check(fmt.Sprintf("%s/page%d", ts.URL, i)); status.StatusCode != http.StatusOK {
+				t.Errorf("expected 200, got %d", status.StatusCode)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", maxInFlight)
+	}
+}
+
+// test the token bucket spends the burst and then refills at the rate
+func TestHostLimiterRate(t *testing.T) {
+	now := time.Now()
+	limiter := NewHostLimiter(HostLimits{RequestsPerSecond: 2, Burst: 2})
+	limiter.now = func() time.Time { return now }
+	state := limiter.host("example.com")
+
+	for i := 0; i < 2; i++ {
+		if wait := limiter.take(state); wait != 0 {
+			t.Fatalf("expected burst request %d to pass, got wait %v", i, wait)
+		}
+	}
+	if wait := limiter.take(state); wait != 500*time.Millisecond {
+		t.Errorf("expected to wait 500ms for the next token, got %v", wait)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if wait := limiter.take(state); wait != 0 {
+		t.Errorf("expected a refilled token, got wait %v", wait)
+	}
+
+	limiter.PauseUntil("example.com", now.Add(3*time.Second))
+	if wait := limiter.take(state); wait != 3*time.Second {
+		t.Errorf("expected the pause to hold the host for 3s, got %v", wait)
+	}
+}
+
+// test waiting gives up with the context
+func TestHostLimiterAcquireCanceled(t *testing.T) {
+	limiter := NewHostLimiter(HostLimits{MaxConcurrent: 1})
+	release, err := limiter.Acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, "EXAMPLE.com"); err == nil {
+		t.Error("expected the second acquire to time out")
+	}
+}
+
+// test a 429 with a short Retry-After is retried once
+func TestLinkCheckerRetryAfter(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	checker := NewLinkChecker(5 * time.Second).WithHostLimiter(NewHostLimiter(HostLimits{MaxRetryAfter: time.Second}))
+	if status := checker.Check(ts.URL); status.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 after the retry, got %d", status.StatusCode)
+	}
+	if hits != 2 {
+		t.Errorf("expected 2 requests, got %d", hits)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"120":                           2 * time.Minute,
+		"Wed, 01 Jan 2025 12:00:30 GMT": 30 * time.Second,
+	}
+	for value, expected := range cases {
+		if delay, ok := parseRetryAfter(value, now); !ok || delay != expected {
+			t.Errorf("Retry-After %q: expected %v, got %v %v", value, expected, delay, ok)
+		}
+	}
+	for _, value := range []string{"", "soon", "-5"} {
+		if _, ok := parseRetryAfter(value, now); ok {
+			t.Errorf("Retry-After %q: expected to be ignored", value)
+		}
+	}
+}
+
+// test a long Retry-After pauses the host for at most MaxRetryAfter and is not retried
+func TestLinkCheckerRetryAfterCapped(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	limiter := NewHostLimiter(HostLimits{MaxRetryAfter: 50 * time.Millisecond})
+	checker := NewLinkChecker(5 * time.Second).WithHostLimiter(limiter)
+	if status := checker.Check(ts.URL); status.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected the 429 to be reported, got %d", status.StatusCode)
+	}
+
+	start := time.Now()
+	release, err := limiter.Acquire(context.Background(), ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("expected the pause to be capped, waited %v", waited)
+	}
+}
+
+// test waiting out a pause does not hold a slot, and the wait ends with the context
+func TestHostLimiterPauseHoldsNoSlot(t *testing.T) {
+	limiter := NewHostLimiter(HostLimits{MaxConcurrent: 1})
+	limiter.PauseUntil("example.com", time.Now().Add(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, "example.com"); err == nil {
+		t.Fatal("expected the paused host to time out")
+	}
+	if n := len(limiter.host("example.com").slots); n != 0 {
+		t.Errorf("expected no slot to be held while paused, got %d", n)
+	}
+}
+
+// test hosts idle for hostIdleTimeout are forgotten
+func TestHostLimiterEvictsIdleHosts(t *testing.T) {
+	now := time.Now()
+	limiter := NewHostLimiter(HostLimits{MaxConcurrent: 1})
+	limiter.now = func() time.Time { return now }
+	limiter.host("old.example.com")
+
+	now = now.Add(hostIdleTimeout)
+	limiter.host("new.example.com")
+	if _, ok := limiter.hosts["old.example.com"]; ok {
+		t.Error("expected the idle host to be evicted")
+	}
+	if len(limiter.hosts) != 1 {
+		t.Errorf("expected only the new host, got %d hosts", len(limiter.hosts))
+	}
+}
+
+// test a redirect hop waits for the limits of its own host, within the request timeout
+func TestLinkCheckerLimitsRedirectHops(t *testing.T) {
+	var targetHits int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&targetHits, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer ts.Close()
+
+	limiter := NewHostLimiter(HostLimits{})
+	limiter.PauseUntil(target.Listener.Addr().String(), time.Now().Add(time.Hour))
+	start := time.Now()
+	status := NewLinkChecker(100 * time.Millisecond).WithHostLimiter(limiter).Check(ts.URL)
+	if status.Failure != FailureTimeout || atomic.LoadInt32(&targetHits) != 0 {
+		t.Errorf("expected the paused redirect target to time out unreached, got %+v with %d hits", status, targetHits)
+	}
+	if waited := time.Since(start); waited > 2*time.Second {
+		t.Errorf("expected the wait to end with the request timeout, waited %v", waited)
+	}
+}
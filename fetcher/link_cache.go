@@ -0,0 +1,124 @@
+package fetcher
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LinkCacheTTLs sets how long a link status stays cached by its outcome, zero disables caching it
+type LinkCacheTTLs struct {
+	Success     time.Duration // 2xx
+	Redirect    time.Duration // 3xx
+	ClientError time.Duration // 4xx
+	Error       time.Duration // 5xx and failed requests
+}
+
+// LinkCache keeps link statuses between analyses, bounded by entry count with LRU eviction
+type LinkCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttls       LinkCacheTTLs
+	entries    map[string]*list.Element
+	lru        *list.List // front is the most recently used
+	now        func() time.Time
+}
+
+type linkCacheEntry struct {
+	url     string
+	status  LinkStatus
+	expires time.Time
+}
+
+var (
+	sharedLinkCacheMu sync.RWMutex
+	sharedLinkCache   *LinkCache
+)
+
+// NewLinkCache returns an empty cache holding at most maxEntries statuses
+func NewLinkCache(maxEntries int, ttls LinkCacheTTLs) *LinkCache {
+	return &LinkCache{
+		maxEntries: maxEntries,
+		ttls:       ttls,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// SetSharedLinkCache sets the cache the analyses share, nil disables caching
+func SetSharedLinkCache(cache *LinkCache) {
+	sharedLinkCacheMu.Lock()
+	defer sharedLinkCacheMu.Unlock()
+	sharedLinkCache = cache
+}
+
+// SharedLinkCache returns the cache the analyses share, nil when caching is disabled
+func SharedLinkCache() *LinkCache {
+	sharedLinkCacheMu.RLock()
+	defer sharedLinkCacheMu.RUnlock()
+	return sharedLinkCache
+}
+
+// Get returns the cached status of url if it has not expired
+func (c *LinkCache) Get(url string) (LinkStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[url]
+	if !ok {
+		return LinkStatus{}, false
+	}
+	entry := elem.Value.(*linkCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, url)
+		return LinkStatus{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.status, true
+}
+
+// Put stores the status of url for the ttl of its outcome, evicting the least recently used entries
+func (c *LinkCache) Put(url string, status LinkStatus) {
+	ttl := c.ttl(status)
+	if ttl <= 0 || c.maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &linkCacheEntry{url: url, status: status, expires: c.now().Add(ttl)}
+	if elem, ok := c.entries[url]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[url] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*linkCacheEntry).url)
+	}
+}
+
+// Len returns the number of cached statuses, including expired ones not yet evicted
+func (c *LinkCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *LinkCache) ttl(status LinkStatus) time.Duration {
+	switch {
+	case status.Err != nil || status.StatusCode == 0 || status.StatusCode >= 500:
+		return c.ttls.Error
+	case status.StatusCode >= 400:
+		return c.ttls.ClientError
+	case status.StatusCode >= 300:
+		return c.ttls.Redirect
+	}
+	return c.ttls.Success
+}
@@ -0,0 +1,90 @@
+package fetcher
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// test statuses expire by their outcome ttl
+func TestLinkCacheTTLs(t *testing.T) {
+	now := time.Now()
+	cache := NewLinkCache(10, LinkCacheTTLs{Success: time.Hour, Error: time.Minute})
+	cache.now = func() time.Time { return now }
+
+	cache.Put("https://example.com/ok", LinkStatus{StatusCode: http.StatusOK})
+	cache.Put("https://example.com/down", LinkStatus{Err: errors.New("connection refused")})
+	cache.Put("https://example.com/missing", LinkStatus{StatusCode: http.StatusNotFound})
+
+	if _, ok := cache.Get("https://example.com/missing"); ok {
+		t.Error("expected 4xx not to be cached with a zero ttl")
+	}
+	if _, ok := cache.Get("https://example.com/down"); !ok {
+		t.Error("expected the failed check to be cached")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get("https://example.com/down"); ok {
+		t.Error("expected the failed check to expire after a minute")
+	}
+	if status, ok := cache.Get("https://example.com/ok"); !ok || status.StatusCode != http.StatusOK {
+		t.Errorf("expected the 200 to still be cached, got %v %v", status, ok)
+	}
+}
+
+// test the least recently used entry is evicted first
+func TestLinkCacheEviction(t *testing.T) {
+	cache := NewLinkCache(2, LinkCacheTTLs{Success: time.Hour})
+	cache.Put("a", LinkStatus{StatusCode: http.StatusOK})
+	cache.Put("b", LinkStatus{StatusCode: http.StatusOK})
+	cache.Get("a")
+	cache.Put("c", LinkStatus{StatusCode: http.StatusOK})
+
+	if cache.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", cache.Len())
+	}
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+}
+
+// test checkers share results through the cache and bypass skips the lookup
+func TestLinkCheckerCache(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	cache := NewLinkCache(10, LinkCacheTTLs{Success: time.Hour})
+
+	first := NewLinkChecker(5*time.Second).WithCache(cache, false).Check(ts.URL)
+	if first.FromCache {
+		t.Error("expected the first check not to come from the cache")
+	}
+
+	second := NewLinkChecker(5*time.Second).WithCache(cache, false).Check(ts.URL)
+	if !second.FromCache || second.StatusCode != http.StatusOK {
+		t.Errorf("expected a cached 200, got %d from cache %v", second.StatusCode, second.FromCache)
+	}
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Errorf("expected 1 request, got %d", hits)
+	}
+
+	bypassed := NewLinkChecker(5*time.Second).WithCache(cache, true).Check(ts.URL)
+	if bypassed.FromCache {
+		t.Error("expected the bypassing check not to come from the cache")
+	}
+	if atomic.LoadInt32(&hits) != 2 {
+		t.Errorf("expected the bypass to send a request, got %d requests", hits)
+	}
+}
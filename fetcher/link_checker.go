@@ -0,0 +1,394 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// LinkStatus is the outcome of checking one link
+type LinkStatus struct {
+	StatusCode    int
+	Latency       int64 // milliseconds
+	ContentLength int64 // -1 when unknown
+	ContentType   string
+	Timing        RequestTiming
+	Err           error
+	FromCache     bool          // served from the shared LinkCache
+	Attempts      int           // requests sent, including the GET fallback and retries
+	Failure       FailureReason // why the check failed, empty for 1xx to 3xx
+}
+
+type linkCheck struct {
+	done   chan struct{}
+	status LinkStatus
+}
+
+// pageFetch keeps what the checks need of a fetched page, not its document, so many pages stay cheap
+type pageFetch struct {
+	done       chan struct{}
+	statusCode int
+	err        error
+	anchors    map[string]bool
+	title      string
+	heading    string
+	text       string
+}
+
+// newPageFetch summarizes a parsed document
+func newPageFetch(doc *goquery.Document) *pageFetch {
+	return &pageFetch{
+		anchors: DocumentAnchors(doc),
+		title:   normalizeSpace(doc.Find("title").First().Text()),
+		heading: normalizeSpace(doc.Find("h1").First().Text()),
+		text:    bodyText(doc),
+	}
+}
+
+// Largest target page read when looking up fragment anchors or soft 404s
+const maxCheckedPageBytes = 5 * 1024 * 1024
+
+// LinkChecker checks link status and shares the results between the analyzers of one analysis
+type LinkChecker struct {
+	client *http.Client
+	mu     sync.Mutex
+	checks map[string]*linkCheck
+	pages  map[string]*pageFetch
+	probes map[string]*pageFetch
+	// Random path fetched on each host to learn its not found response
+	probePath string
+	// The analyzed page, a probe matching it means the host serves one app shell for every path
+	analyzed       *pageFetch
+	analyzedOrigin string
+	// Linked pages fetched for the soft 404 check so far
+	softChecks int
+
+	// Optional cache shared between analyses, bypass skips the lookups but still stores fresh results
+	cache       *LinkCache
+	bypassCache bool
+
+	// Optional per host politeness shared between analyses
+	limiter *HostLimiter
+
+	retry RetryPolicy
+
+	// Context of the analysis, waiting for a host and the requests end with it
+	ctx context.Context
+}
+
+// NewLinkChecker returns a LinkChecker with the given per request timeout and the default User-Agent
+func NewLinkChecker(timeout time.Duration) *LinkChecker {
+	return NewLinkCheckerWithClient(&http.Client{
+		Timeout:       timeout,
+		Transport:     &profileTransport{base: sharedTransport, userAgent: ResolveUserAgent("")},
+		CheckRedirect: checkRedirect,
+	})
+}
+
+// NewLinkCheckerWithClient returns a LinkChecker sending its requests through client
+func NewLinkCheckerWithClient(client *http.Client) *LinkChecker {
+	return &LinkChecker{
+		client: client,
+		checks: make(map[string]*linkCheck),
+		pages:  make(map[string]*pageFetch),
+		probes: make(map[string]*pageFetch),
+	}
+}
+
+// WithCache makes the checker read and store statuses in cache, with bypass it only stores them
+func (c *LinkChecker) WithCache(cache *LinkCache, bypass bool) *LinkChecker {
+	c.cache = cache
+	c.bypassCache = bypass
+	return c
+}
+
+// WithHostLimiter makes the checker wait for the per host concurrency and rate limits of limiter
+// before every request, redirects included
+func (c *LinkChecker) WithHostLimiter(limiter *HostLimiter) *LinkChecker {
+	c.limiter = limiter
+	if limiter != nil {
+		// A copy, the client may be shared with the page fetch which is not limited
+		client := *c.client
+		client.Transport = &limitedTransport{base: client.Transport, limiter: limiter}
+		c.client = &client
+	}
+	return c
+}
+
+// WithContext ties the requests and the waits for the host limits to the analysis
+func (c *LinkChecker) WithContext(ctx context.Context) *LinkChecker {
+	c.ctx = ctx
+	return c
+}
+
+// WithRetryPolicy makes the checker retry transient failures by policy
+func (c *LinkChecker) WithRetryPolicy(policy RetryPolicy) *LinkChecker {
+	c.retry = policy
+	return c
+}
+
+// Check returns the status of the url, running the request only once per url
+func (c *LinkChecker) Check(url string) LinkStatus {
+	// The fragment is never sent, so /docs#a and /docs#b share one check
+	url = StripFragment(url)
+
+	c.mu.Lock()
+	check, exists := c.checks[url]
+	if !exists {
+		check = &linkCheck{done: make(chan struct{})}
+		c.checks[url] = check
+	}
+	c.mu.Unlock()
+
+	if exists {
+		<-check.done
+		return check.status
+	}
+
+	check.status = c.cachedCheck(url)
+	close(check.done)
+	return check.status
+}
+
+// cachedCheck serves the status from the cache when possible and stores fresh results
+func (c *LinkChecker) cachedCheck(url string) LinkStatus {
+	if c.cache == nil {
+		return c.check(url)
+	}
+	if !c.bypassCache {
+		if status, ok := c.cache.Get(url); ok {
+			status.FromCache = true
+			return status
+		}
+	}
+	status := c.check(url)
+	c.cache.Put(url, status)
+	return status
+}
+
+// check sends a HEAD request, confirms unsupported HEAD with a ranged GET
+// and retries transient failures by the retry policy
+func (c *LinkChecker) check(url string) LinkStatus {
+	start := time.Now()
+	method := http.MethodHead
+	attempts, retries := 0, 0
+	waitedRetryAfter := false
+
+	for {
+		attempts++
+		resp, tracer, err := c.send(method, url)
+
+		switch {
+		case method == http.MethodHead && isHeadUnsupported(resp, err):
+			closeBody(resp)
+			method = http.MethodGet
+			continue
+		case !waitedRetryAfter && err == nil && c.retryAfter(resp):
+			// Wait out a short Retry-After once, the limiter holds back the other requests to the host meanwhile
+			closeBody(resp)
+			waitedRetryAfter = true
+			continue
+		case retries < c.retry.MaxRetries && isTransientFailure(resp, err) && c.context().Err() == nil:
+			closeBody(resp)
+			if err := c.sleep(c.retry.backoff(retries)); err != nil {
+				status := failedStatus(start, tracer, err)
+				status.Attempts = attempts
+				return status
+			}
+			retries++
+			continue
+		}
+
+		if err != nil {
+			status := failedStatus(start, tracer, err)
+			status.Attempts = attempts
+			return status
+		}
+		status := rangedStatus(resp)
+		status.Failure = FailureForStatus(status.StatusCode)
+		resp.Body.Close()
+		status.Latency = time.Since(start).Milliseconds()
+		status.Timing = tracer.finish(resp.Proto)
+		status.Attempts = attempts
+		return status
+	}
+}
+
+// send sends a check request, GET asks for the first byte only to avoid downloading large bodies
+func (c *LinkChecker) send(method string, url string) (*http.Response, *requestTracer, error) {
+	var header http.Header
+	if method == http.MethodGet {
+		header = http.Header{"Range": []string{"bytes=0-0"}}
+	}
+	return c.do(method, url, header)
+}
+
+// rangedStatus reads the status of a response, a partial answer to the ranged GET counts as 200
+// and a range beyond the end as an empty 200
+func rangedStatus(resp *http.Response) LinkStatus {
+	status := LinkStatus{
+		StatusCode:    resp.StatusCode,
+		ContentLength: resp.ContentLength,
+		ContentType:   resp.Header.Get("Content-Type"),
+	}
+	if resp.Request == nil || resp.Request.Header.Get("Range") == "" {
+		return status
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		status.StatusCode = http.StatusOK
+		status.ContentLength = -1
+		// Content-Range: bytes 0-0/12345
+		contentRange := resp.Header.Get("Content-Range")
+		if i := strings.LastIndex(contentRange, "/"); i >= 0 {
+			if total, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err == nil {
+				status.ContentLength = total
+			}
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		status.StatusCode = http.StatusOK
+		status.ContentLength = 0
+	}
+	return status
+}
+
+func closeBody(resp *http.Response) {
+	if resp != nil {
+		resp.Body.Close()
+	}
+}
+
+// failedStatus marks the url as unreachable; status code 0
+func failedStatus(start time.Time, tracer *requestTracer, err error) LinkStatus {
+	return LinkStatus{
+		Latency:       time.Since(start).Milliseconds(),
+		ContentLength: -1,
+		Timing:        tracer.finish(""),
+		Err:           err,
+		Failure:       ClassifyFailure(err),
+	}
+}
+
+// retryAfter pauses the host of a 429 or 503 response for its Retry-After, at most MaxRetryAfter,
+// and reports whether the delay is short enough to retry
+func (c *LinkChecker) retryAfter(resp *http.Response) bool {
+	if c.limiter == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return false
+	}
+	delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		return false
+	}
+	// A host asking for an hour must not stall every analysis that long
+	pause := min(delay, c.limiter.limits.MaxRetryAfter)
+	if pause > 0 {
+		c.limiter.PauseUntil(resp.Request.URL.Host, time.Now().Add(pause))
+	}
+	return delay <= c.limiter.limits.MaxRetryAfter
+}
+
+// sleep waits for delay, returning early with the error of the analysis context when it ends
+func (c *LinkChecker) sleep(delay time.Duration) error {
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-c.context().Done():
+		return c.context().Err()
+	}
+}
+
+// context returns the context of the analysis, background without one
+func (c *LinkChecker) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// do sends one traced request, the transport waits for the host limits of every hop
+func (c *LinkChecker) do(method string, url string, header http.Header) (*http.Response, *requestTracer, error) {
+	req, err := http.NewRequestWithContext(c.context(), method, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	tracer, ctx := newRequestTracer(req.Context())
+	resp, err := c.client.Do(req.WithContext(ctx))
+	return resp, tracer, err
+}
+
+// Anchors returns the ids and a[name] values of the page, fetching each page only once
+func (c *LinkChecker) Anchors(pageUrl string) (map[string]bool, error) {
+	fetch := c.fetchPage(c.pages, StripFragment(pageUrl))
+	if fetch.err != nil {
+		return nil, fetch.err
+	}
+	return fetch.anchors, nil
+}
+
+// fetchPage GETs and summarizes the page once per url of memo, an error status is kept with its summary
+func (c *LinkChecker) fetchPage(memo map[string]*pageFetch, pageUrl string) *pageFetch {
+	c.mu.Lock()
+	fetch, exists := memo[pageUrl]
+	if !exists {
+		fetch = &pageFetch{done: make(chan struct{})}
+		memo[pageUrl] = fetch
+	}
+	c.mu.Unlock()
+
+	if exists {
+		<-fetch.done
+		return fetch
+	}
+	defer close(fetch.done)
+
+	resp, _, err := c.do(http.MethodGet, pageUrl, nil)
+	if err != nil {
+		fetch.err = err
+		return fetch
+	}
+	defer resp.Body.Close()
+
+	fetch.statusCode = resp.StatusCode
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxCheckedPageBytes))
+	if err != nil {
+		fetch.err = err
+		return fetch
+	}
+	summary := newPageFetch(doc)
+	fetch.anchors, fetch.title, fetch.heading, fetch.text = summary.anchors, summary.title, summary.heading, summary.text
+	if resp.StatusCode >= 400 {
+		fetch.err = errors.New(resp.Status)
+	}
+	return fetch
+}
+
+// DocumentAnchors returns the fragment targets of a document, every id and a[name]
+func DocumentAnchors(doc *goquery.Document) map[string]bool {
+	anchors := make(map[string]bool)
+	doc.Find("[id]").Each(func(_ int, s *goquery.Selection) {
+		anchors[s.AttrOr("id", "")] = true
+	})
+	doc.Find("a[name]").Each(func(_ int, s *goquery.Selection) {
+		anchors[s.AttrOr("name", "")] = true
+	})
+	return anchors
+}
+
+// StripFragment removes the #fragment of a url
+func StripFragment(url string) string {
+	if i := strings.Index(url, "#"); i >= 0 {
+		return url[:i]
+	}
+	return url
+}
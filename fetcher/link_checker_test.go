@@ -0,0 +1,63 @@
+package fetcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// test the checker runs one request per url and shares the result
+func TestLinkCheckerSharesResults(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Length", "42")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	checker := NewLinkChecker(5 * time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := checker.Check(ts.URL)
+			if status.StatusCode != http.StatusOK || status.ContentLength != 42 {
+				t.Errorf("unexpected status %+v", status)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits != 1 {
+		t.Errorf("expected 1 request, got %d", hits)
+	}
+}
+
+// test the checker reports the timing breakdown and connection reuse
+func TestLinkCheckerTiming(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	checker := NewLinkChecker(5 * time.Second)
+
+	first := checker.Check(ts.URL + "/a")
+	if first.Timing.Protocol != "HTTP/1.1" || first.Timing.ConnectionReused {
+		t.Errorf("unexpected first timing %+v", first.Timing)
+	}
+	if first.Timing.TotalMs <= 0 || first.Timing.TimeToFirstByteMs <= 0 {
+		t.Errorf("expected phases to be recorded, got %+v", first.Timing)
+	}
+
+	second := checker.Check(ts.URL + "/b")
+	if !second.Timing.ConnectionReused || second.Timing.TCPConnectMs != 0 {
+		t.Errorf("expected reused connection, got %+v", second.Timing)
+	}
+}
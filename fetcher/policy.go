@@ -0,0 +1,73 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedByPolicy is returned for requests the network policy does not allow
+var ErrBlockedByPolicy = errors.New("blocked by policy")
+
+// Redirect errors, the client stops following redirects with these
+var (
+	ErrRedirectLoop      = errors.New("redirect loop")
+	ErrTooManyRedirects  = errors.New("too many redirects")
+	maxRedirects         = 10
+	blockPrivateNetworks atomic.Bool
+)
+
+// Transport shared by the page fetch and the link checks, dials go through the network policy
+var sharedTransport = newTransport()
+
+// SetBlockPrivateNetworks makes every request to loopback, private and link local addresses fail
+func SetBlockPrivateNetworks(block bool) {
+	blockPrivateNetworks.Store(block)
+}
+
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   policyControl,
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// policyControl runs for every resolved address before connecting, so dns can not point around it
+func policyControl(_ string, address string, _ syscall.RawConn) error {
+	if !blockPrivateNetworks.Load() {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s is not a public address", ErrBlockedByPolicy, ip)
+	}
+	return nil
+}
+
+// checkRedirect stops at the first url visited twice and after maxRedirects redirects
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	for _, previous := range via {
+		if previous.URL.String() == req.URL.String() {
+			return fmt.Errorf("%w at %s", ErrRedirectLoop, req.URL)
+		}
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("%w, stopped after %d", ErrTooManyRedirects, len(via))
+	}
+	return nil
+}
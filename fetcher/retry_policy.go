@@ -0,0 +1,83 @@
+package fetcher
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy retries link checks that failed for a transient reason with exponential backoff
+type RetryPolicy struct {
+	MaxRetries int           // extra attempts after the first, 0 never retries
+	BaseDelay  time.Duration // delay before the first retry, doubled for every next one
+	MaxDelay   time.Duration // upper bound of a single delay
+}
+
+var (
+	retryPolicyMu      sync.RWMutex
+	defaultRetryPolicy RetryPolicy
+)
+
+// SetDefaultRetryPolicy sets the policy the analyses use
+func SetDefaultRetryPolicy(policy RetryPolicy) {
+	retryPolicyMu.Lock()
+	defer retryPolicyMu.Unlock()
+	defaultRetryPolicy = policy
+}
+
+// DefaultRetryPolicy returns the policy the analyses use
+func DefaultRetryPolicy() RetryPolicy {
+	retryPolicyMu.RLock()
+	defer retryPolicyMu.RUnlock()
+	return defaultRetryPolicy
+}
+
+// backoff returns the jittered delay before the given retry, counted from 0
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay << retry
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Equal jitter keeps at least half the delay and spreads the rest
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// isTransientFailure reports whether a check failed for a reason worth retrying:
+// timeouts, connection resets and 5xx other than 501
+func isTransientFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true
+		}
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+// isHeadUnsupported reports whether a HEAD response should be confirmed with GET,
+// servers answer HEAD with an error, 403, 405 or 501 while GET works. A timeout, a dns
+// failure or a canceled analysis would fail the GET the same way
+func isHeadUnsupported(resp *http.Response, err error) bool {
+	if err != nil {
+		switch ClassifyFailure(err) {
+		case FailureTimeout, FailureDNSNotFound, FailureDNSError, FailureCanceled:
+			return false
+		}
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// test HEAD answered with 405 is confirmed with a ranged GET
+func TestLinkCheckerHeadFallback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") != "bytes=0-0" {
+			t.Errorf("expected a ranged GET, got Range %q", r.Header.Get("Range"))
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Range", "bytes 0-0/123456")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte("%"))
+	}))
+	defer ts.Close()
+
+	status := NewLinkChecker(5 * time.Second).Check(ts.URL)
+	if status.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", status.StatusCode)
+	}
+	if status.ContentLength != 123456 {
+		t.Errorf("expected the full length from Content-Range, got %d", status.ContentLength)
+	}
+	if status.Attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", status.Attempts)
+	}
+}
+
+// test 5xx is retried up to MaxRetries
+func TestLinkCheckerRetries(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	policy := RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	status := NewLinkChecker(5 * time.Second).WithRetryPolicy(policy).Check(ts.URL)
+	if status.StatusCode != http.StatusOK || status.Attempts != 3 {
+		t.Errorf("expected 200 after 3 attempts, got %d after %d", status.StatusCode, status.Attempts)
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	policy.MaxRetries = 1
+	status = NewLinkChecker(5 * time.Second).WithRetryPolicy(policy).Check(ts.URL)
+	if status.StatusCode != http.StatusBadGateway || status.Attempts != 2 {
+		t.Errorf("expected 502 after 2 attempts, got %d after %d", status.StatusCode, status.Attempts)
+	}
+}
+
+// test timeouts are retried without falling back to GET
+func TestLinkCheckerRetriesTimeout(t *testing.T) {
+	var hits, gets int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			atomic.AddInt32(&gets, 1)
+		}
+		if atomic.AddInt32(&hits, 1) <= 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	status := NewLinkChecker(50 * time.Millisecond).WithRetryPolicy(RetryPolicy{MaxRetries: 1}).Check(ts.URL)
+	if status.StatusCode != http.StatusOK || status.Attempts != 2 {
+		t.Errorf("expected 200 after 2 attempts, got %d after %d (%v)", status.StatusCode, status.Attempts, status.Err)
+	}
+	if atomic.LoadInt32(&gets) != 0 {
+		t.Errorf("expected a HEAD timeout not to be confirmed with GET, got %d GETs", gets)
+	}
+}
+
+// test the backoff ends with the analysis and no retry follows
+func TestLinkCheckerRetriesStopWithContext(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: 10 * time.Second, MaxDelay: 10 * time.Second}
+	start := time.Now()
+	status := NewLinkChecker(5 * time.Second).WithRetryPolicy(policy).WithContext(ctx).Check(ts.URL)
+	if time.Since(start) > 2*time.Second || atomic.LoadInt32(&hits) != 1 {
+		t.Errorf("expected the check to stop with the context, took %v with %d requests", time.Since(start), hits)
+	}
+	if status.Failure != FailureCanceled && status.Failure != FailureTimeout {
+		t.Errorf("expected a canceled check, got %+v", status)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	bounds := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for retry, max := range bounds {
+		for i := 0; i < 20; i++ {
+			if delay := policy.backoff(retry); delay < max/2 || delay > max {
+				t.Fatalf("retry %d: expected a delay between %v and %v, got %v", retry, max/2, max, delay)
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package fetcher
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SoftNotFound is the outcome of the soft 404 check of a page answered with 2xx
+type SoftNotFound struct {
+	Suspected bool     `json:"suspected"`
+	Evidence  []string `json:"evidence"`
+}
+
+const (
+	// Pages at least this similar to the random path probe use the not found template
+	softNotFoundSimilarity = 0.85
+	// Not found wording in the body only counts on short pages, long pages mention "404" for other reasons
+	maxSoftNotFoundBodyWords = 300
+	// Linked pages fetched for the check per analysis, later links are not checked
+	maxSoftNotFoundChecks = 25
+)
+
+// Typical wording of not found pages
+var notFoundRegex = regexp.MustCompile(`(?i)\b404\b|not found|page (?:could not|cannot|can't|can not) be found|(?:doesn't|does not|no longer) exists?|no longer available|page (?:is )?unavailable|nothing (?:was )?found|couldn't find (?:that|the|this) page`)
+
+// SoftNotFound checks a url answered with 2xx for not found signals and against a probe of a random path on its host
+func (c *LinkChecker) SoftNotFound(pageUrl string) SoftNotFound {
+	pageUrl = StripFragment(pageUrl)
+	c.mu.Lock()
+	if _, fetched := c.pages[pageUrl]; !fetched {
+		if c.softChecks >= maxSoftNotFoundChecks {
+			c.mu.Unlock()
+			return SoftNotFound{Evidence: []string{}}
+		}
+		c.softChecks++
+	}
+	c.mu.Unlock()
+
+	fetch := c.fetchPage(c.pages, pageUrl)
+	if fetch.err != nil || fetch.statusCode < 200 || fetch.statusCode >= 300 {
+		return SoftNotFound{Evidence: []string{}}
+	}
+	return c.softNotFound(fetch, pageUrl)
+}
+
+// SetAnalyzedPage tells the checker the page under analysis, a random path answered with that same page is an app shell, not a not found template
+func (c *LinkChecker) SetAnalyzedPage(doc *goquery.Document, pageUrl string) {
+	page := newPageFetch(doc)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.analyzed = page
+	c.analyzedOrigin = origin(pageUrl)
+}
+
+// PageSoftNotFound checks an already fetched page, pageUrl gives the host to probe
+func (c *LinkChecker) PageSoftNotFound(doc *goquery.Document, pageUrl string) SoftNotFound {
+	return c.softNotFound(newPageFetch(doc), pageUrl)
+}
+
+func (c *LinkChecker) softNotFound(page *pageFetch, pageUrl string) SoftNotFound {
+	result := SoftNotFound{Evidence: []string{}}
+
+	if match := notFoundRegex.FindString(page.title); match != "" {
+		result.Suspected = true
+		result.Evidence = append(result.Evidence, fmt.Sprintf("title %q contains %q", page.title, match))
+	}
+	if match := notFoundRegex.FindString(page.heading); match != "" {
+		result.Suspected = true
+		result.Evidence = append(result.Evidence, fmt.Sprintf("heading %q contains %q", page.heading, match))
+	}
+	if words := strings.Fields(page.text); len(words) <= maxSoftNotFoundBodyWords {
+		if match := notFoundRegex.FindString(page.text); match != "" && len(result.Evidence) == 0 {
+			result.Suspected = true
+			result.Evidence = append(result.Evidence, fmt.Sprintf("short page (%d words) contains %q", len(words), match))
+		}
+	}
+
+	// A host answering a random path with 2xx serves its not found template that way
+	probe := c.probe(pageUrl)
+	if probe == nil || probe.err != nil || probe.statusCode < 200 || probe.statusCode >= 300 {
+		return result
+	}
+	similarity, matches := samePage(page, probe)
+	if !matches {
+		return result
+	}
+	// Unless the random path got the analyzed page itself, then every path gets the app shell and the probe tells nothing
+	c.mu.Lock()
+	analyzed, analyzedOrigin := c.analyzed, c.analyzedOrigin
+	c.mu.Unlock()
+	if analyzed != nil && analyzedOrigin == origin(pageUrl) {
+		if _, shell := samePage(analyzed, probe); shell {
+			return result
+		}
+	}
+	result.Suspected = true
+	result.Evidence = append(result.Evidence, fmt.Sprintf("matches the %d response for a random path on the host (%.0f%% similar)", probe.statusCode, similarity*100))
+	return result
+}
+
+// samePage reports whether two pages have the same title and nearly the same text
+func samePage(a, b *pageFetch) (float64, bool) {
+	similarity := textSimilarity(a.text, b.text)
+	return similarity, similarity >= softNotFoundSimilarity && a.title == b.title
+}
+
+// probe fetches a random path on the host of pageUrl, once per host
+func (c *LinkChecker) probe(pageUrl string) *pageFetch {
+	host := origin(pageUrl)
+	if host == "" {
+		return nil
+	}
+
+	// The path is random per checker so no real page or cache answers it
+	c.mu.Lock()
+	if c.probePath == "" {
+		c.probePath = "/" + randomToken() + "-page-check"
+	}
+	probeUrl := host + c.probePath
+	c.mu.Unlock()
+
+	return c.fetchPage(c.probes, probeUrl)
+}
+
+// origin returns the scheme and host of a url, empty when it has no host
+func origin(pageUrl string) string {
+	parsed, err := url.Parse(pageUrl)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// textSimilarity is the jaccard similarity of the word sets of a and b, 0 when both are empty
+func textSimilarity(a, b string) float64 {
+	wordsA := wordSet(a)
+	wordsB := wordSet(b)
+	union := len(wordsA)
+	shared := 0
+	for w := range wordsB {
+		if wordsA[w] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+func wordSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		set[w] = true
+	}
+	return set
+}
+
+// bodyText returns the text of the body without scripts and styles
+func bodyText(doc *goquery.Document) string {
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript, template").Remove()
+	return normalizeSpace(body.Text())
+}
+
+func normalizeSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func randomToken() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "0000000000000000"
+	}
+	return hex.EncodeToString(b)
+}
+
+// IsHTMLContentType reports whether a Content-Type is an html page worth a soft 404 check
+func IsHTMLContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.Contains(contentType, "text/html") || strings.Contains(contentType, "application/xhtml")
+}
@@ -0,0 +1,115 @@
+package fetcher
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const notFoundTemplate = `<html><head><title>Acme</title></head><body>
+	<nav>Home Products About Contact</nav>
+	<p>Sorry, we looked everywhere but could not locate what you asked for. Try the search.</p>
+	</body></html>`
+
+// softNotFoundServer answers known pages and every other path with 200 and the same template
+func softNotFoundServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/about":
+			io.WriteString(w, `<html><head><title>About Acme</title></head><body>
+				<nav>Home Products About Contact</nav>
+				<h1>About us</h1><p>Acme builds rockets, anvils and other fine products since 1949.</p>
+				</body></html>`)
+		case "/missing-product":
+			io.WriteString(w, `<html><head><title>Product not found</title></head><body><p>Gone.</p></body></html>`)
+		default:
+			io.WriteString(w, notFoundTemplate)
+		}
+	}))
+}
+
+func TestSoftNotFound(t *testing.T) {
+	ts := softNotFoundServer()
+	defer ts.Close()
+	checker := NewLinkChecker(5 * time.Second)
+
+	if result := checker.SoftNotFound(ts.URL + "/about"); result.Suspected {
+		t.Errorf("expected /about not to be suspected, got %v", result.Evidence)
+	}
+
+	result := checker.SoftNotFound(ts.URL + "/old-page")
+	if !result.Suspected || len(result.Evidence) != 1 || !strings.Contains(result.Evidence[0], "random path") {
+		t.Errorf("expected /old-page to match the probe, got %v", result)
+	}
+
+	result = checker.SoftNotFound(ts.URL + "/missing-product")
+	if !result.Suspected || !strings.Contains(result.Evidence[0], "title") {
+		t.Errorf("expected the not found title to be evidence, got %v", result)
+	}
+}
+
+// test hosts answering unknown paths with a real 404 are only judged by the page signals
+func TestSoftNotFoundHardNotFoundHost(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/page" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		io.WriteString(w, notFoundTemplate)
+	}))
+	defer ts.Close()
+
+	if result := NewLinkChecker(5 * time.Second).SoftNotFound(ts.URL + "/page"); result.Suspected {
+		t.Errorf("expected no soft 404 on a host with real 404s, got %v", result.Evidence)
+	}
+}
+
+// test single page apps answer every path with the same shell, the probe matching it is no evidence
+func TestSoftNotFoundAppShell(t *testing.T) {
+	const shell = `<html><head><title>Acme App</title></head><body>
+		<div id="root">Loading the Acme dashboard, please enable JavaScript to continue.</div>
+		</body></html>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, shell)
+	}))
+	defer ts.Close()
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(shell))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := NewLinkChecker(5 * time.Second)
+	checker.SetAnalyzedPage(doc, ts.URL+"/")
+
+	if result := checker.SoftNotFound(ts.URL + "/pricing"); result.Suspected {
+		t.Errorf("expected an app shell link not to be suspected, got %v", result.Evidence)
+	}
+	if result := checker.PageSoftNotFound(doc, ts.URL+"/"); result.Suspected {
+		t.Errorf("expected the app shell page not to be suspected, got %v", result.Evidence)
+	}
+}
+
+func TestSoftNotFoundCapsFetchedLinks(t *testing.T) {
+	var fetched atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/page-") {
+			fetched.Add(1)
+		}
+		io.WriteString(w, notFoundTemplate)
+	}))
+	defer ts.Close()
+
+	checker := NewLinkChecker(5 * time.Second)
+	for i := 0; i < maxSoftNotFoundChecks+5; i++ {
+		checker.SoftNotFound(fmt.Sprintf("%s/page-%d", ts.URL, i))
+	}
+	if got := fetched.Load(); got != maxSoftNotFoundChecks {
+		t.Errorf("expected %d fetched links, got %d", maxSoftNotFoundChecks, got)
+	}
+}
@@ -0,0 +1,127 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"math"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestTiming is the phase breakdown of one request in milliseconds
+type RequestTiming struct {
+	DNSLookupMs       float64 `json:"dnsLookupMs"`
+	TCPConnectMs      float64 `json:"tcpConnectMs"`
+	TLSHandshakeMs    float64 `json:"tlsHandshakeMs"`
+	TimeToFirstByteMs float64 `json:"timeToFirstByteMs"`
+	ContentTransferMs float64 `json:"contentTransferMs"`
+	TotalMs           float64 `json:"totalMs"`
+	ConnectionReused  bool    `json:"connectionReused"`
+	Protocol          string  `json:"protocol,omitempty"` // e.g. HTTP/1.1 or HTTP/2.0
+}
+
+// requestTracer records the httptrace events of one request
+type requestTracer struct {
+	mu         sync.Mutex
+	start      time.Time
+	dnsStart   time.Time
+	dnsDone    time.Time
+	connStart  time.Time
+	connDone   time.Time
+	tlsStart   time.Time
+	tlsDone    time.Time
+	wroteReq   time.Time
+	firstByte  time.Time
+	reused     bool
+	negotiated string
+}
+
+type requestTracerKey struct{}
+
+// newRequestTracer returns a tracer and the context carrying its hooks
+func newRequestTracer(ctx context.Context) (*requestTracer, context.Context) {
+	t := &requestTracer{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { t.set(&t.dnsDone) },
+		ConnectStart:      func(string, string) { t.setOnce(&t.connStart) },
+		ConnectDone:       func(string, string, error) { t.set(&t.connDone) },
+		TLSHandshakeStart: func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone: func(state tls.ConnectionState, _ error) {
+			t.set(&t.tlsDone)
+			t.mu.Lock()
+			t.negotiated = state.NegotiatedProtocol
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.set(&t.wroteReq) },
+		GotFirstResponseByte: func() { t.set(&t.firstByte) },
+	}
+	return t, httptrace.WithClientTrace(context.WithValue(ctx, requestTracerKey{}, t), trace)
+}
+
+// excludeWait moves the start of the tracer of ctx past a wait for the host limits,
+// so the timing only covers the request itself
+func excludeWait(ctx context.Context, wait time.Duration) {
+	if t, ok := ctx.Value(requestTracerKey{}).(*requestTracer); ok {
+		t.mu.Lock()
+		t.start = t.start.Add(wait)
+		t.mu.Unlock()
+	}
+}
+
+func (t *requestTracer) set(field *time.Time) {
+	t.mu.Lock()
+	*field = time.Now()
+	t.mu.Unlock()
+}
+
+// setOnce keeps the first connect start when several addresses are dialed
+func (t *requestTracer) setOnce(field *time.Time) {
+	t.mu.Lock()
+	if field.IsZero() {
+		*field = time.Now()
+	}
+	t.mu.Unlock()
+}
+
+// finish builds the timing once the body was read, proto is the response protocol
+func (t *requestTracer) finish(proto string) RequestTiming {
+	// No tracer when the request was never sent
+	if t == nil {
+		return RequestTiming{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	end := time.Now()
+	timing := RequestTiming{
+		DNSLookupMs:      phaseMs(t.dnsStart, t.dnsDone),
+		TCPConnectMs:     phaseMs(t.connStart, t.connDone),
+		TLSHandshakeMs:   phaseMs(t.tlsStart, t.tlsDone),
+		TotalMs:          phaseMs(t.start, end),
+		ConnectionReused: t.reused,
+		Protocol:         proto,
+	}
+	if !t.firstByte.IsZero() {
+		timing.TimeToFirstByteMs = phaseMs(t.start, t.firstByte)
+		timing.ContentTransferMs = phaseMs(t.firstByte, end)
+	}
+	if timing.Protocol == "" && t.negotiated == "h2" {
+		timing.Protocol = "HTTP/2.0"
+	}
+	return timing
+}
+
+// phaseMs returns the milliseconds between two events, 0 when one did not happen
+func phaseMs(from time.Time, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return math.Round(float64(to.Sub(from).Microseconds())/10) / 100
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/responses"
+	"github.com/janithT/webpage-analyzer/store"
+)
+
+// Stored analysis handler, pages through the links of an earlier analysis without analyzing again
+func GetAnalysisHandler(ginC *gin.Context) {
+	linkQuery, err := analyzers.ParseLinkQuery(ginC.Request.URL.Query())
+	if err != nil {
+		responses.WriteError(ginC, http.StatusBadRequest, responses.CodeInvalidParameter, err.Error(), nil)
+		return
+	}
+
+	analysisStore := store.SharedAnalysisStore()
+	if analysisStore == nil {
+		responses.WriteError(ginC, http.StatusNotFound, responses.CodeNotFound, "Analyses are not stored.", nil)
+		return
+	}
+	analysis, ok := analysisStore.Get(ginC.Param("id"))
+	if !ok {
+		responses.WriteError(ginC, http.StatusNotFound, responses.CodeNotFound, "Analysis not found or expired.", map[string]interface{}{"id": ginC.Param("id")})
+		return
+	}
+
+	responses.WriteSuccess(ginC, "Stored analysis", withLinkQuery(analysis.Data, linkQuery))
+}
+
+// withLinkQuery returns data with the filtered, sorted and paginated links, the counts stay those of all links
+func withLinkQuery(data map[string]interface{}, query analyzers.LinkQuery) map[string]interface{} {
+	urls, ok := data["urls"].(map[string]interface{})
+	if !ok || query.IsZero() {
+		return data
+	}
+	links, ok := urls["links"].([]analyzers.LinkProperty)
+	if !ok {
+		return data
+	}
+	page := query.Apply(links)
+
+	// Copy the maps, data may be shared with the store
+	queriedUrls := make(map[string]interface{}, len(urls)+1)
+	for key, value := range urls {
+		queriedUrls[key] = value
+	}
+	queriedUrls["links"] = page.Links
+	queriedUrls["pagination"] = page.Pagination
+
+	queried := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		queried[key] = value
+	}
+	queried["urls"] = queriedUrls
+	return queried
+}
@@ -1,55 +1,134 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/janithT/webpage-analyzer/analyzers"
 	"github.com/janithT/webpage-analyzer/fetcher"
 	"github.com/janithT/webpage-analyzer/pool"
 	"github.com/janithT/webpage-analyzer/responses"
+	"github.com/janithT/webpage-analyzer/store"
 )
 
 // Main analyze handler
 func AnalyzeHandler(ginC *gin.Context) {
 	// Get url parameter
 	url := strings.TrimSpace(ginC.Query("url"))
-	log.Printf("Trimmed url = %v", url)
+	log.Printf("[%s] Trimmed url = %v", responses.RequestID(ginC), fetcher.RedactURL(url))
 
 	// Validate URL
 	if !fetcher.IsValidURL(url) || !fetcher.IsRegexValidURL(url) {
-		responses.WriteError(ginC, http.StatusBadRequest, "Invalid URL format")
+		responses.WriteError(ginC, http.StatusBadRequest, responses.CodeInvalidURL, "Invalid URL format", map[string]interface{}{"url": url})
 		return
 	}
 
-	doc, raw, status, err := fetcher.FetchAndParse(url)
+	// Filters, sort and page of the returned links
+	linkQuery, err := analyzers.ParseLinkQuery(ginC.Request.URL.Query())
 	if err != nil {
-		// Handle errors
-		if status == http.StatusForbidden {
-			responses.WriteError(ginC, http.StatusForbidden, "URL not accessible or blocked.")
+		responses.WriteError(ginC, http.StatusBadRequest, responses.CodeInvalidParameter, err.Error(), nil)
+		return
+	}
+
+	// Internal link scope, the configured default unless the request picks one
+	classification := analyzers.DefaultLinkClassification()
+	if scope := ginC.Query("linkScope"); scope != "" {
+		parsed, err := analyzers.ParseLinkScope(scope)
+		if err != nil {
+			responses.WriteError(ginC, http.StatusBadRequest, responses.CodeInvalidParameter, err.Error(), nil)
 			return
 		}
-		if strings.Contains(strings.ToLower(err.Error()), "no such host") ||
-			strings.Contains(strings.ToLower(err.Error()), "server is misbehaving") ||
-			strings.Contains(strings.ToLower(err.Error()), "lookup") {
-			responses.WriteError(ginC, http.StatusBadRequest, "Host not found. Check domain name.")
+		classification.Scope = parsed
+	}
+
+	// noCache=true rechecks every link instead of using the shared cache
+	bypassCache, err := boolQuery(ginC, "noCache")
+	if err != nil {
+		responses.WriteError(ginC, http.StatusBadRequest, responses.CodeInvalidParameter, err.Error(), nil)
+		return
+	}
+
+	// checkMx=true looks up the mail exchangers of mailto links
+	checkMx, err := boolQuery(ginC, "checkMx")
+	if err != nil {
+		responses.WriteError(ginC, http.StatusBadRequest, responses.CodeInvalidParameter, err.Error(), nil)
+		return
+	}
+	var mxResolver analyzers.MXResolver
+	if checkMx {
+		mxResolver = net.DefaultResolver
+	}
+
+	// User-Agent, headers, cookies, credentials and proxy, the configured profile with the overrides of the request
+	profile, err := clientProfileFromRequest(ginC, url)
+	if err != nil {
+		responses.WriteError(ginC, http.StatusBadRequest, responses.CodeInvalidParameter, err.Error(), nil)
+		return
+	}
+	// Optional authentication step picked by name, its credentials only come from the configuration
+	authStep, err := authStepFromRequest(ginC, url)
+	if err != nil {
+		responses.WriteError(ginC, http.StatusBadRequest, responses.CodeInvalidParameter, err.Error(), nil)
+		return
+	}
+
+	// The page fetch and the link checks share the client, and so the cookies the site sets
+	client, err := profile.NewClient(url, 10*time.Second)
+	if err != nil {
+		responses.WriteError(ginC, http.StatusInternalServerError, responses.CodeInternal, err.Error(), nil)
+		return
+	}
+	if authStep != nil {
+		if err := authStep.Authenticate(ginC.Request.Context(), client, url); err != nil {
+			log.Printf("[%s] Authentication %s failed: %v", responses.RequestID(ginC), authStep.Name, err)
+			responses.WriteError(ginC, http.StatusBadGateway, responses.CodeAuthFailed, err.Error(), map[string]interface{}{"auth": authStep.Name})
 			return
 		}
-		responses.WriteError(ginC, status, err.Error())
+	}
+
+	// A page analyzed before with the same options is only downloaded and analyzed again when it changed,
+	// noCache=true always analyzes it
+	variant := analysisVariant(classification, checkMx, profile, authStep)
+	analysisStore := store.SharedAnalysisStore()
+	var previous *store.Analysis
+	var since fetcher.Validators
+	if !bypassCache {
+		previous, since = previousValidators(analysisStore, url, variant)
+	}
+
+	page, status, err := fetcher.FetchPageIfModified(ginC.Request.Context(), url, client, since)
+	if err != nil {
+		writeFetchError(ginC, status, err, authStep)
+		return
+	}
+	if page.NotModified {
+		responses.WriteSuccess(ginC, "Page unchanged since the previous analysis, its results are replayed", withLinkQuery(unchangedData(previous, page), linkQuery))
 		return
 	}
 
-	analyzersList := []analyzers.Analyzer{
-		analyzers.HTMLVersionAnalyzer(),
-		analyzers.TitleAnalyzer(),
-		analyzers.HeadingAnalyzer(),
-		analyzers.LoginFormAnalyzer(),
-		analyzers.LinkAnalyzer(),
+	// Statuses seen with credentials are not shared with other analyses
+	linkCache := fetcher.SharedLinkCache()
+	if profile.HasCredentials() || authStep != nil {
+		linkCache = nil
 	}
 
-	results := pool.ExecuteAnalyzers(analyzersList, doc, raw)
+	// One checker per analysis so analyzers share the link checks
+	checker := fetcher.NewLinkCheckerWithClient(client).
+		WithCache(linkCache, bypassCache).
+		WithHostLimiter(fetcher.SharedHostLimiter()).
+		WithRetryPolicy(fetcher.DefaultRetryPolicy()).
+		WithContext(ginC.Request.Context())
+
+	analyzersList := analyzersFor(page, checker, classification, mxResolver)
+
+	results := pool.ExecuteAnalyzers(analyzersList, page.Doc, page.Raw)
 
 	data := make(map[string]interface{})
 	for _, result := range results {
@@ -59,6 +138,164 @@ func AnalyzeHandler(ginC *gin.Context) {
 		}
 		data[result.Key] = result.Value
 	}
+	data["pageTiming"] = page.Timing
+	data["unchanged"] = false
+	data["contentType"] = map[string]interface{}{
+		"declared": page.ContentType,
+		"detected": page.MimeType,
+		"kind":     page.ContentKind,
+	}
+
+	if authStep != nil {
+		data["authentication"] = map[string]interface{}{"profile": authStep.Name, "method": authStep.Method()}
+		// Pages behind a login may show the username or echo the session
+		data = redactData(data, authStep.Redact)
+	}
+
+	// Keep the full result so later requests can page through the links or revalidate the page,
+	// the id is in data before it is stored since a stored analysis is shared with other requests
+	if analysisStore != nil {
+		id := store.NewID()
+		data["analysisId"] = id
+		analysisStore.SaveAnalysis(&store.Analysis{
+			ID:           id,
+			Url:          url,
+			Data:         data,
+			ETag:         page.Validators.ETag,
+			LastModified: page.Validators.LastModified,
+			Variant:      variant,
+		})
+	}
+
+	responses.WriteSuccess(ginC, "Analyzed successfully", withLinkQuery(data, linkQuery))
+}
+
+// Query parameters that look like credentials, they would end up in access logs and browser histories
+var credentialQueryParams = []string{"username", "user", "password", "pass", "token", "secret", "apiKey", "authorization"}
+
+// authStepFromRequest returns the authentication step named by the auth parameter, nil without one.
+// The step must be for the host of pageUrl, its session is never sent elsewhere
+func authStepFromRequest(ginC *gin.Context, pageUrl string) (*fetcher.AuthStep, error) {
+	for _, name := range credentialQueryParams {
+		if _, ok := ginC.GetQuery(name); ok {
+			return nil, fmt.Errorf("credentials can not be passed in the query string, configure an auth profile and pass auth=<name>")
+		}
+	}
+	name := strings.TrimSpace(ginC.Query("auth"))
+	if name == "" {
+		return nil, nil
+	}
+	step, ok := fetcher.AuthStepByName(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown auth profile %q", name)
+	}
+	if !step.AllowsURL(pageUrl) {
+		return nil, fmt.Errorf("auth profile %q is not for the host of the url", name)
+	}
+	return &step, nil
+}
 
-	responses.WriteSuccess(ginC, "Analyzed successfully", data)
+// analyzersFor returns the analyzers that make sense for the kind of the page.
+// XHTML is parsed like HTML and gets every analyzer, SVG and plain text only those that read their content
+func analyzersFor(page *fetcher.Page, checker *fetcher.LinkChecker, classification analyzers.LinkClassification, mxResolver analyzers.MXResolver) []analyzers.Analyzer {
+	switch page.ContentKind {
+	case fetcher.ContentSVG:
+		return []analyzers.Analyzer{
+			analyzers.TitleAnalyzer(),
+			analyzers.LinkAnalyzer(checker, classification, mxResolver),
+			analyzers.MixedContentAnalyzer(),
+			analyzers.ThirdPartyAnalyzer(checker),
+		}
+	case fetcher.ContentText:
+		return []analyzers.Analyzer{
+			analyzers.ContentAnalyzer(),
+			analyzers.LanguageAnalyzer(page.Header.Get("Content-Language")),
+		}
+	}
+	return []analyzers.Analyzer{
+		analyzers.HTMLVersionAnalyzer(),
+		analyzers.TitleAnalyzer(),
+		analyzers.HeadingAnalyzer(),
+		analyzers.LoginFormAnalyzer(),
+		analyzers.FormAnalyzer(),
+		analyzers.FormSecurityAnalyzer(),
+		analyzers.ContentAnalyzer(),
+		analyzers.LanguageAnalyzer(page.Header.Get("Content-Language")),
+		analyzers.LinkAnalyzer(checker, classification, mxResolver),
+		analyzers.MixedContentAnalyzer(),
+		analyzers.TechStackAnalyzer(page.Header, page.Cookies),
+		analyzers.ThirdPartyAnalyzer(checker),
+		analyzers.PerformanceAnalyzer(checker, page.TransferSize, page.ContentEncoding),
+		analyzers.ImageAnalyzer(checker),
+	}
+}
+
+// boolQuery reads an optional true or false query parameter
+func boolQuery(ginC *gin.Context, name string) (bool, error) {
+	value := ginC.Query(name)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return parsed, nil
+}
+
+// writeFetchError answers a failed page fetch with the error code of its kind, the secrets of
+// authStep are redacted from the message and the log
+func writeFetchError(ginC *gin.Context, status int, err error, authStep *fetcher.AuthStep) {
+	message := err.Error()
+	if authStep != nil {
+		message = authStep.Redact(message)
+	}
+	var fetchErr *fetcher.FetchError
+	if !errors.As(err, &fetchErr) {
+		responses.WriteError(ginC, http.StatusInternalServerError, responses.CodeInternal, message, nil)
+		return
+	}
+
+	details := map[string]interface{}{"kind": fetchErr.Kind}
+	if fetchErr.Reason != "" {
+		details["reason"] = fetchErr.Reason
+	}
+	if fetchErr.StatusCode != 0 {
+		details["upstream_status"] = fetchErr.StatusCode
+	}
+	if fetchErr.ContentType != "" {
+		details["content_type"] = fetchErr.ContentType
+	}
+	if fetchErr.DetectedType != "" {
+		details["detected_type"] = fetchErr.DetectedType
+	}
+	log.Printf("[%s] Fetch failed: %s", responses.RequestID(ginC), message)
+
+	switch fetchErr.Kind {
+	case fetcher.FetchInvalidURL:
+		responses.WriteError(ginC, http.StatusBadRequest, responses.CodeInvalidURL, message, details)
+	case fetcher.FetchDNSFailure:
+		responses.WriteError(ginC, http.StatusBadRequest, responses.CodeDNSFailure, "Host not found. Check domain name.", details)
+	case fetcher.FetchTimeout:
+		responses.WriteError(ginC, http.StatusGatewayTimeout, responses.CodeUpstreamTimeout, "The page did not respond in time.", details)
+	case fetcher.FetchUpstreamClientError:
+		if fetchErr.StatusCode == http.StatusForbidden {
+			message = "URL not accessible or blocked."
+		}
+		// The upstream status is passed on, the analyzed page is what was not found or forbidden
+		responses.WriteError(ginC, status, responses.CodeUpstream4xx, message, details)
+	case fetcher.FetchUpstreamServerError:
+		responses.WriteError(ginC, http.StatusBadGateway, responses.CodeUpstream5xx, message, details)
+	case fetcher.FetchBlockedByPolicy:
+		responses.WriteError(ginC, http.StatusForbidden, responses.CodeBlockedByPolicy, "URL is not allowed by the network policy.", details)
+	case fetcher.FetchPageTooLarge:
+		details["max_bytes"] = fetcher.MaxPageBytes
+		responses.WriteError(ginC, http.StatusUnprocessableEntity, responses.CodePageTooLarge, message, details)
+	case fetcher.FetchUnsupportedContentType:
+		responses.WriteError(ginC, http.StatusUnprocessableEntity, responses.CodeUnsupportedContentType, message, details)
+	case fetcher.FetchInvalidContent:
+		responses.WriteError(ginC, http.StatusBadGateway, responses.CodeInvalidContent, message, details)
+	default:
+		responses.WriteError(ginC, http.StatusBadGateway, responses.CodeUpstreamUnreachable, message, details)
+	}
 }
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"golang.org/x/net/http/httpguts"
+)
+
+// Request headers whose values are sent as Authorization and Cookie to the analyzed host, credentials never go in the query string
+const (
+	targetAuthorizationHeader = "X-Target-Authorization"
+	targetCookieHeader        = "X-Target-Cookie"
+)
+
+// Headers a request may not set through the header parameter, they have their own parameters or are secrets
+var reservedProfileHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Host":          true,
+}
+
+// clientProfileFromRequest returns the default client profile with the overrides of the request:
+// userAgent, header=Name: value, proxy and insecureSkipVerify, which only the configured insecure hosts accept,
+// and the X-Target-Authorization and X-Target-Cookie headers
+func clientProfileFromRequest(ginC *gin.Context, pageUrl string) (fetcher.ClientProfile, error) {
+	profile := fetcher.DefaultClientProfile()
+
+	if userAgent := strings.TrimSpace(ginC.Query("userAgent")); userAgent != "" {
+		profile.UserAgent = userAgent
+	}
+
+	for _, header := range ginC.QueryArray("header") {
+		name, value, found := strings.Cut(header, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !found || !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return profile, fmt.Errorf("header must be like Name: value")
+		}
+		if reservedProfileHeaders[http.CanonicalHeaderKey(name)] {
+			return profile, fmt.Errorf("header %s can not be set, use the %s or %s header", name, targetCookieHeader, targetAuthorizationHeader)
+		}
+		profile.Headers.Set(name, value)
+	}
+
+	if _, ok := ginC.GetQuery("cookie"); ok {
+		return profile, fmt.Errorf("cookies can not be passed in the query string, use the %s header", targetCookieHeader)
+	}
+	if cookies := strings.TrimSpace(ginC.GetHeader(targetCookieHeader)); cookies != "" {
+		parsed, err := http.ParseCookie(cookies)
+		if err != nil {
+			return profile, fmt.Errorf("%s must be like name=value; other=value", targetCookieHeader)
+		}
+		for _, cookie := range parsed {
+			profile.TargetCookies[cookie.Name] = cookie.Value
+		}
+	}
+
+	if proxy := strings.TrimSpace(ginC.Query("proxy")); proxy != "" {
+		parsed, err := fetcher.ParseProxy(proxy)
+		if err != nil {
+			return profile, err
+		}
+		if parsed.User != nil {
+			return profile, fmt.Errorf("proxy credentials can not be passed in the query string, configure the proxy in the client profile")
+		}
+		profile.Proxy = proxy
+	}
+
+	insecure, err := boolQuery(ginC, "insecureSkipVerify")
+	if err != nil {
+		return profile, err
+	}
+	if insecure {
+		page, err := url.Parse(pageUrl)
+		if err != nil || !profile.AllowsInsecure(page.Hostname()) {
+			return profile, fmt.Errorf("insecureSkipVerify is only allowed for the configured insecure hosts")
+		}
+		profile.InsecureSkipVerify = true
+	}
+
+	if authorization := strings.TrimSpace(ginC.GetHeader(targetAuthorizationHeader)); authorization != "" {
+		if err := setTargetAuthorization(&profile, authorization); err != nil {
+			return profile, err
+		}
+	}
+	return profile, nil
+}
+
+// setTargetAuthorization takes the basic or bearer credentials of the request, they are only sent to the analyzed host
+func setTargetAuthorization(profile *fetcher.ClientProfile, authorization string) error {
+	scheme, credentials, _ := strings.Cut(authorization, " ")
+	credentials = strings.TrimSpace(credentials)
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		if credentials == "" {
+			break
+		}
+		profile.TargetAuthorization = "Bearer " + credentials
+		return nil
+	case "basic":
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			break
+		}
+		user, _, found := strings.Cut(string(decoded), ":")
+		if !found || user == "" {
+			break
+		}
+		profile.TargetAuthorization = "Basic " + credentials
+		return nil
+	}
+	return fmt.Errorf("%s must be Basic or Bearer credentials", targetAuthorizationHeader)
+}
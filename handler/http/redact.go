@@ -0,0 +1,82 @@
+package http
+
+import (
+	"reflect"
+)
+
+// redactData returns data with redact applied to every string in it, values without secrets are kept as they are
+func redactData(data map[string]interface{}, redact func(string) string) map[string]interface{} {
+	redactedData := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		redactedData[key] = redactValue(value, redact)
+	}
+	return redactedData
+}
+
+// redactValue returns a copy of value with redact applied to the raw strings, before any encoding escapes them
+func redactValue(value interface{}, redact func(string) string) interface{} {
+	if value == nil {
+		return nil
+	}
+	return redactReflect(reflect.ValueOf(value), redact).Interface()
+}
+
+// redactReflect copies v, walking pointers, interfaces, structs, slices, arrays and maps down to their strings
+func redactReflect(v reflect.Value, redact func(string) string) reflect.Value {
+	switch v.Kind() {
+	case reflect.String:
+		out := reflect.New(v.Type()).Elem()
+		out.SetString(redact(v.String()))
+		return out
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(redactReflect(v.Elem(), redact))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(redactReflect(v.Elem(), redact))
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		// Unexported fields are kept as they are, they are not in the response
+		for i := 0; i < v.NumField(); i++ {
+			if out.Field(i).CanSet() {
+				out.Field(i).Set(redactReflect(v.Field(i), redact))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redactReflect(v.Index(i), redact))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redactReflect(v.Index(i), redact))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(redactReflect(iter.Key(), redact), redactReflect(iter.Value(), redact))
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/janithT/webpage-analyzer/analyzers"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"github.com/janithT/webpage-analyzer/store"
+)
+
+// Key of the variant fingerprints, random per process so a stored fingerprint can not be
+// checked against guessed credentials
+var variantKey = func() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}()
+
+// analysisVariant fingerprints the options that change the results of an analysis, analyses of the
+// same url are only reused with the same options. Credentials are part of it, so one user never
+// gets the results of another, and the fingerprint is an hmac with variantKey, so the store holds
+// nothing the credentials can be recovered or confirmed from
+func analysisVariant(classification analyzers.LinkClassification, checkMx bool, profile fetcher.ClientProfile, authStep *fetcher.AuthStep) string {
+	authName := ""
+	if authStep != nil {
+		authName = authStep.Name
+	}
+	// fmt prints maps sorted by key, so equal options give equal fingerprints
+	options := fmt.Sprintf("scope=%s firstParty=%v checkMx=%t ua=%s headers=%v cookies=%v basic=%s:%s bearer=%s credentialHosts=%v target=%s targetCookies=%v proxy=%s insecure=%t:%v auth=%s",
+		classification.Scope, classification.FirstPartyDomains, checkMx,
+		fetcher.ResolveUserAgent(profile.UserAgent), map[string][]string(profile.Headers), profile.Cookies,
+		profile.BasicAuthUser, profile.BasicAuthPassword, profile.BearerToken, profile.CredentialHosts, profile.TargetAuthorization, profile.TargetCookies,
+		profile.Proxy, profile.InsecureSkipVerify, profile.InsecureHosts, authName)
+	mac := hmac.New(sha256.New, variantKey)
+	mac.Write([]byte(options))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// previousValidators returns the latest analysis of url with the variant and its page validators
+func previousValidators(analysisStore *store.AnalysisStore, url string, variant string) (*store.Analysis, fetcher.Validators) {
+	if analysisStore == nil {
+		return nil, fetcher.Validators{}
+	}
+	previous, ok := analysisStore.Latest(url, variant)
+	if !ok {
+		return nil, fetcher.Validators{}
+	}
+	return previous, fetcher.Validators{ETag: previous.ETag, LastModified: previous.LastModified}
+}
+
+// unchangedData returns the results of the previous analysis for a page answered with 304 Not Modified.
+// Only the results are replayed, the 304 has no body so the page is neither parsed nor analyzed again
+func unchangedData(previous *store.Analysis, page *fetcher.Page) map[string]interface{} {
+	// Copy the map, the stored results are shared
+	data := make(map[string]interface{}, len(previous.Data)+2)
+	for key, value := range previous.Data {
+		data[key] = value
+	}
+	data["unchanged"] = true
+	data["analyzedAt"] = previous.CreatedAt.Format(time.RFC3339)
+	data["pageTiming"] = page.Timing
+	return data
+}
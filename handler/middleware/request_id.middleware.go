@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/janithT/webpage-analyzer/responses"
+)
+
+// RequestID keeps a valid X-Request-ID of the client or generates one, and echoes it in the response
+func RequestID() gin.HandlerFunc {
+	return func(ginC *gin.Context) {
+		id := ginC.GetHeader(responses.RequestIDHeader)
+		if !responses.IsValidRequestID(id) {
+			id = responses.NewRequestID()
+		}
+		responses.SetRequestID(ginC, id)
+		ginC.Header(responses.RequestIDHeader, id)
+		ginC.Next()
+	}
+}
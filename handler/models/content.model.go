@@ -0,0 +1,23 @@
+package models
+
+// Keyword or n-gram statistic model
+type KeywordStat struct {
+	Term    string  `json:"term"`
+	Count   int     `json:"count"`
+	Density float64 `json:"density"` // percent of all words
+}
+
+// Content and readability report model
+type ContentReport struct {
+	WordCount           int           `json:"wordCount"`
+	SentenceCount       int           `json:"sentenceCount"`
+	CharacterCount      int           `json:"characterCount"`
+	ReadingTimeSeconds  int           `json:"readingTimeSeconds"`
+	AvgWordsPerSentence float64       `json:"avgWordsPerSentence"`
+	FleschReadingEase   float64       `json:"fleschReadingEase"`
+	FleschKincaidGrade  float64       `json:"fleschKincaidGrade"`
+	TextToHTMLRatio     float64       `json:"textToHtmlRatio"` // percent
+	Keywords            []KeywordStat `json:"keywords"`
+	Bigrams             []KeywordStat `json:"bigrams"`
+	Trigrams            []KeywordStat `json:"trigrams"`
+}
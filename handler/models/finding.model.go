@@ -0,0 +1,18 @@
+package models
+
+// Finding severities
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Analyzer finding model, rule ids are stable so clients can filter on them
+type Finding struct {
+	RuleID      string   `json:"ruleId"`
+	Severity    string   `json:"severity"`
+	Message     string   `json:"message"`
+	Count       int      `json:"count,omitempty"`
+	Items       []string `json:"items,omitempty"`
+	ScoreImpact int      `json:"scoreImpact,omitempty"`
+}
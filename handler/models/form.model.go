@@ -0,0 +1,49 @@
+package models
+
+// Form field model
+type FormField struct {
+	Tag          string `json:"tag"`
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	Id           string `json:"id,omitempty"`
+	Autocomplete string `json:"autocomplete,omitempty"`
+	Placeholder  string `json:"placeholder,omitempty"`
+	Label        string `json:"label,omitempty"`
+	Value        string `json:"-"`
+	Required     bool   `json:"required"`
+}
+
+// Form submit button model
+type FormButton struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// Form model with its classification
+type FormInfo struct {
+	Index          int          `json:"index"`
+	Id             string       `json:"id,omitempty"`
+	Action         string       `json:"action"`
+	Method         string       `json:"method"`
+	Hidden         bool         `json:"hidden"`
+	Fields         []FormField  `json:"fields"`
+	Buttons        []FormButton `json:"buttons"`
+	Classification string       `json:"classification"`
+	Confidence     int          `json:"confidence"`
+	Signals        []string     `json:"signals"`
+}
+
+// Single sign on button model
+type SSOButton struct {
+	Provider string `json:"provider"`
+	Text     string `json:"text"`
+	Url      string `json:"url,omitempty"`
+}
+
+// Forms report model
+type FormsReport struct {
+	FormCount    int            `json:"formCount"`
+	ByType       map[string]int `json:"byType"`
+	Forms        []FormInfo     `json:"forms"`
+	SSOProviders []SSOButton    `json:"ssoProviders"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// Form security and usability report model
+type FormSecurityReport struct {
+	FormCount      int            `json:"formCount"`
+	SeverityCounts map[string]int `json:"severityCounts"`
+	Findings       []Finding      `json:"findings"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// Image inventory entry model
+type ImageInfo struct {
+	Url       string `json:"url"`
+	Source    string `json:"source"` // img, srcset, picture or css
+	Alt       string `json:"alt"`
+	HasAlt    bool   `json:"hasAlt"`
+	Width     string `json:"width,omitempty"`
+	Height    string `json:"height,omitempty"`
+	Format    string `json:"format"`
+	Bytes     int64  `json:"bytes"` // -1 when unknown
+	HasSrcset bool   `json:"hasSrcset"`
+	InPicture bool   `json:"inPicture"`
+}
+
+// Image inventory report model
+type ImageReport struct {
+	ImageCount      int            `json:"imageCount"`
+	TotalKnownBytes int64          `json:"totalKnownBytes"`
+	FormatCounts    map[string]int `json:"formatCounts"`
+	Images          []ImageInfo    `json:"images"`
+	Findings        []Finding      `json:"findings"`
+}
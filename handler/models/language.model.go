@@ -0,0 +1,22 @@
+package models
+
+// Language of one page section model
+type LanguageSection struct {
+	Selector     string  `json:"selector"`
+	DeclaredLang string  `json:"declaredLang,omitempty"`
+	DetectedLang string  `json:"detectedLang"`
+	Confidence   float64 `json:"confidence"`
+	WordCount    int     `json:"wordCount"`
+}
+
+// Language report model, the detection comes from a small bundled trigram model and is a hint
+type LanguageReport struct {
+	DetectedLang    string             `json:"detectedLang"`
+	Confidence      float64            `json:"confidence"` // at most 0.5, the model is a hint
+	Hint            bool               `json:"hint"`
+	HTMLLang        string             `json:"htmlLang,omitempty"`
+	ContentLanguage string             `json:"contentLanguage,omitempty"`
+	Distribution    map[string]float64 `json:"distribution"` // percent of words per language
+	Sections        []LanguageSection  `json:"sections"`
+	Findings        []Finding          `json:"findings"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// Mixed content item model
+type MixedContentItem struct {
+	Url       string `json:"url"`
+	Tag       string `json:"tag"`
+	Attribute string `json:"attribute"`
+}
+
+// Mixed content report model
+type MixedContentReport struct {
+	PageIsHTTPS       bool               `json:"pageIsHttps"`
+	ActiveCount       int                `json:"activeCount"`
+	PassiveCount      int                `json:"passiveCount"`
+	InsecureFormCount int                `json:"insecureFormCount"`
+	Active            []MixedContentItem `json:"active"`
+	Passive           []MixedContentItem `json:"passive"`
+	InsecureForms     []MixedContentItem `json:"insecureForms"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// Non http link model, a mailto, tel, ftp, javascript or data link found in the page
+type NonHttpLink struct {
+	Url         string   `json:"url"`
+	Scheme      string   `json:"scheme"`
+	Sources     []string `json:"sources"`
+	Occurrences int      `json:"occurrences"`
+	Valid       bool     `json:"valid"`
+	// E.164 number of tel links, addresses of mailto links
+	Normalized string   `json:"normalized,omitempty"`
+	Addresses  []string `json:"addresses,omitempty"`
+	MxStatus   string   `json:"mx_status,omitempty"`
+	// Media type and decoded size of data uris
+	MediaType string `json:"media_type,omitempty"`
+	Bytes     int    `json:"bytes,omitempty"`
+	Issue     string `json:"issue,omitempty"`
+}
+
+// Non http link inventory model. Css url(data:...) references are not counted,
+// they inline images and fonts rather than link anywhere
+type NonHttpLinkReport struct {
+	TotalCount   int            `json:"total_count"`
+	SchemeCounts map[string]int `json:"scheme_counts"`
+	Links        []NonHttpLink  `json:"links"`
+	Findings     []Finding      `json:"findings"`
+}
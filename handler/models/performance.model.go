@@ -0,0 +1,20 @@
+package models
+
+// Resource weight model for one resource type
+type ResourceWeight struct {
+	Count           int   `json:"count"`
+	TotalKnownBytes int64 `json:"totalKnownBytes"`
+	UnknownSize     int   `json:"unknownSize"`
+}
+
+// Performance report model
+type PerformanceReport struct {
+	Score            int                       `json:"score"`
+	HTMLSize         int64                     `json:"htmlSize"`
+	TransferSize     int64                     `json:"transferSize"`
+	ContentEncoding  string                    `json:"contentEncoding,omitempty"`
+	CompressionRatio float64                   `json:"compressionRatio"`
+	TotalKnownBytes  int64                     `json:"totalKnownBytes"`
+	Resources        map[string]ResourceWeight `json:"resources"`
+	Findings         []Finding                 `json:"findings"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// Technology evidence model
+type TechEvidence struct {
+	Source string `json:"source"`
+	Value  string `json:"value"`
+}
+
+// Detected technology model
+type TechMatch struct {
+	Name       string         `json:"name"`
+	Categories []string       `json:"categories"`
+	Version    string         `json:"version,omitempty"`
+	Confidence int            `json:"confidence"`
+	Website    string         `json:"website,omitempty"`
+	Evidence   []TechEvidence `json:"evidence"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// Third party resource model
+type ThirdPartyResource struct {
+	Url   string `json:"url"`
+	Kind  string `json:"kind"`
+	Bytes int64  `json:"bytes"` // -1 when unknown
+}
+
+// Third party domain model, grouped by registrable domain (eTLD+1)
+type ThirdPartyDomain struct {
+	Domain          string               `json:"domain"`
+	Category        string               `json:"category"`
+	RequestCount    int                  `json:"requestCount"`
+	KindCounts      map[string]int       `json:"kindCounts"`
+	TotalKnownBytes int64                `json:"totalKnownBytes"`
+	Resources       []ThirdPartyResource `json:"resources"`
+}
+
+// Third party inventory model
+type ThirdPartyReport struct {
+	DomainCount     int                `json:"domainCount"`
+	RequestCount    int                `json:"requestCount"`
+	TotalKnownBytes int64              `json:"totalKnownBytes"`
+	CategoryCounts  map[string]int     `json:"categoryCounts"`
+	Domains         []ThirdPartyDomain `json:"domains"`
+}
@@ -9,15 +9,119 @@ import (
 	"os/signal"
 	"time"
 
+	"github.com/janithT/webpage-analyzer/analyzers"
 	channels "github.com/janithT/webpage-analyzer/channel"
 	"github.com/janithT/webpage-analyzer/config"
 	"github.com/janithT/webpage-analyzer/engine"
+	"github.com/janithT/webpage-analyzer/fetcher"
+	"github.com/janithT/webpage-analyzer/store"
 )
 
 func main() {
 	// Get the app configuration from app.yaml
 	conf := config.GetAppConfig()
 
+	// Load the technology signatures once at startup
+	sigs, err := analyzers.LoadTechSignatures(conf.TechSignaturesFile)
+	if err != nil {
+		log.Fatalf("Could not load tech signatures: %v", err)
+	}
+	analyzers.SetTechSignatures(sigs)
+	log.Printf("Loaded %d technology signatures", len(sigs.Technologies))
+
+	// Internal link classification, requests may override the scope
+	scope, err := analyzers.ParseLinkScope(conf.LinkScope)
+	if err != nil {
+		log.Fatalf("Invalid link scope: %v", err)
+	}
+	analyzers.SetDefaultLinkClassification(analyzers.LinkClassification{
+		Scope:             scope,
+		FirstPartyDomains: conf.FirstPartyDomains,
+	})
+
+	// Link statuses are shared between analyses of the same site
+	if cacheConf := conf.LinkCache; cacheConf.MaxEntries > 0 {
+		fetcher.SetSharedLinkCache(fetcher.NewLinkCache(cacheConf.MaxEntries, fetcher.LinkCacheTTLs{
+			Success:     time.Duration(cacheConf.SuccessTtlInSec) * time.Second,
+			Redirect:    time.Duration(cacheConf.RedirectTtlInSec) * time.Second,
+			ClientError: time.Duration(cacheConf.ClientErrorTtlInSec) * time.Second,
+			Error:       time.Duration(cacheConf.ErrorTtlInSec) * time.Second,
+		}))
+	}
+
+	// Per host politeness across all analyses
+	fetcher.SetSharedHostLimiter(fetcher.NewHostLimiter(fetcher.HostLimits{
+		MaxConcurrent:     conf.HostLimits.MaxConcurrentPerHost,
+		RequestsPerSecond: conf.HostLimits.RequestsPerSecond,
+		Burst:             conf.HostLimits.Burst,
+		MaxRetryAfter:     time.Duration(conf.HostLimits.MaxRetryAfterInSec) * time.Second,
+	}))
+
+	// Analyzed pages and links may not point into the private network
+	fetcher.SetBlockPrivateNetworks(conf.BlockPrivateNetworks)
+
+	// Transient link check failures are retried with backoff
+	fetcher.SetDefaultRetryPolicy(fetcher.RetryPolicy{
+		MaxRetries: conf.LinkRetry.MaxRetries,
+		BaseDelay:  time.Duration(conf.LinkRetry.BaseDelayInMs) * time.Millisecond,
+		MaxDelay:   time.Duration(conf.LinkRetry.MaxDelayInMs) * time.Millisecond,
+	})
+
+	// User-Agent, headers, cookies, credentials and proxy of the page fetch and the link checks
+	profileConf := conf.ClientProfile
+	if _, err := fetcher.ParseProxy(profileConf.Proxy); err != nil {
+		log.Fatalf("Invalid client profile: %v", err)
+	}
+	headers := make(http.Header)
+	for name, value := range profileConf.Headers {
+		headers.Set(name, value)
+	}
+	fetcher.SetDefaultClientProfile(fetcher.ClientProfile{
+		UserAgent:          profileConf.UserAgent,
+		Headers:            headers,
+		Cookies:            profileConf.Cookies,
+		BasicAuthUser:      profileConf.BasicAuthUser,
+		BasicAuthPassword:  profileConf.BasicAuthPassword,
+		BearerToken:        profileConf.BearerToken,
+		CredentialHosts:    profileConf.CredentialHosts,
+		Proxy:              profileConf.Proxy,
+		InsecureSkipVerify: profileConf.InsecureSkipVerify,
+		InsecureHosts:      profileConf.InsecureHosts,
+	})
+
+	// Authentication steps, their secrets are read from the environment or the secrets file
+	secrets, err := config.LoadSecrets(conf.SecretsFile)
+	if err != nil {
+		log.Fatalf("Could not load secrets: %v", err)
+	}
+	authSteps := make([]fetcher.AuthStep, 0, len(conf.AuthProfiles))
+	for _, authConf := range conf.AuthProfiles {
+		step := fetcher.AuthStep{
+			Name:          authConf.Name,
+			LoginURL:      authConf.LoginUrl,
+			UsernameField: authConf.UsernameField,
+			PasswordField: authConf.PasswordField,
+			CSRFField:     authConf.CsrfField,
+			ExtraFields:   authConf.ExtraFields,
+			Username:      authConf.Username.Resolve(secrets),
+			Password:      authConf.Password.Resolve(secrets),
+			Cookies:       authConf.Cookies.Resolve(secrets),
+			SessionCookie: authConf.SessionCookie,
+			Hosts:         authConf.Hosts,
+		}
+		if err := step.Validate(); err != nil {
+			log.Fatalf("Invalid authentication profile: %v", err)
+		}
+		authSteps = append(authSteps, step)
+	}
+	fetcher.SetAuthSteps(authSteps)
+	log.Printf("Loaded %d authentication profiles", len(authSteps))
+
+	// Recent analyses can be queried again by id
+	if conf.AnalysisStoreSize > 0 {
+		store.SetSharedAnalysisStore(store.NewAnalysisStore(conf.AnalysisStoreSize))
+	}
+
 	// Start thread pool with 10 workers = 10 set to app.yaml
 	channels.InitializetPageUrlWorkerThreadPool(conf.ThreadCount)
 
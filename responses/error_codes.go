@@ -0,0 +1,21 @@
+package responses
+
+// ErrorCode is the stable, machine readable reason of an error response
+type ErrorCode string
+
+const (
+	CodeInvalidURL             ErrorCode = "INVALID_URL"
+	CodeInvalidParameter       ErrorCode = "INVALID_PARAMETER"
+	CodeNotFound               ErrorCode = "NOT_FOUND"
+	CodeDNSFailure             ErrorCode = "DNS_FAILURE"
+	CodeUpstreamTimeout        ErrorCode = "UPSTREAM_TIMEOUT"
+	CodeUpstream4xx            ErrorCode = "UPSTREAM_4XX"
+	CodeUpstream5xx            ErrorCode = "UPSTREAM_5XX"
+	CodeUpstreamUnreachable    ErrorCode = "UPSTREAM_UNREACHABLE"
+	CodeInvalidContent         ErrorCode = "INVALID_CONTENT"
+	CodeBlockedByPolicy        ErrorCode = "BLOCKED_BY_POLICY"
+	CodePageTooLarge           ErrorCode = "PAGE_TOO_LARGE"
+	CodeUnsupportedContentType ErrorCode = "UNSUPPORTED_CONTENT_TYPE"
+	CodeAuthFailed             ErrorCode = "AUTH_FAILED"
+	CodeInternal               ErrorCode = "INTERNAL_ERROR"
+)
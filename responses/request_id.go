@@ -0,0 +1,49 @@
+package responses
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request id in and out
+const RequestIDHeader = "X-Request-ID"
+
+// Gin context key of the request id
+const requestIDKey = "requestId"
+
+// Longest accepted client request id, longer ones are replaced
+const maxRequestIDLen = 128
+
+// SetRequestID stores the request id of the request
+func SetRequestID(ginC *gin.Context, id string) {
+	ginC.Set(requestIDKey, id)
+}
+
+// RequestID returns the request id of the request, empty when none was set
+func RequestID(ginC *gin.Context) string {
+	return ginC.GetString(requestIDKey)
+}
+
+// NewRequestID returns a random request id
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "00000000000000000000000000000000"
+	}
+	return hex.EncodeToString(b)
+}
+
+// IsValidRequestID reports whether a client request id is safe to echo and log
+func IsValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		if r <= ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
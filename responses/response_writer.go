@@ -10,18 +10,24 @@ import (
 var mu sync.Mutex
 
 type BaseResponse struct {
-	Status  string      `json:"status"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Status    string                 `json:"status"`
+	Code      ErrorCode              `json:"code,omitempty"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Data      interface{}            `json:"data,omitempty"`
 }
 
-// WriteError sends a standardized error response to the client
-func WriteError(ginC *gin.Context, statusCode int, message string) {
+// WriteError sends a standardized error response to the client, details may be nil
+func WriteError(ginC *gin.Context, statusCode int, code ErrorCode, message string, details map[string]interface{}) {
 	mu.Lock()
 	defer mu.Unlock()
 	ginC.JSON(statusCode, BaseResponse{
-		Status:  "error",
-		Message: message,
+		Status:    "error",
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: RequestID(ginC),
 	})
 	ginC.Abort()
 }
@@ -31,8 +37,9 @@ func WriteSuccess(ginC *gin.Context, message string, data interface{}) {
 	mu.Lock()
 	defer mu.Unlock()
 	ginC.JSON(http.StatusOK, BaseResponse{
-		Status:  "success",
-		Message: message,
-		Data:    data,
+		Status:    "success",
+		Message:   message,
+		RequestID: RequestID(ginC),
+		Data:      data,
 	})
 }
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Analysis is a finished analysis kept for later queries
+type Analysis struct {
+	ID        string
+	Url       string
+	CreatedAt time.Time
+	Data      map[string]interface{} // analyzer results by key, not modified once the id is handed out
+	// Validators of the analyzed page, a later analysis of the same url and variant sends them
+	// and reuses Data when the page is not modified
+	ETag         string
+	LastModified string
+	// Fingerprint of the options the page was analyzed with, the results of other options differ
+	Variant string
+}
+
+// AnalysisStore keeps the most recent analyses in memory, bounded by count with LRU eviction
+type AnalysisStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	latest     map[string]*list.Element // by url and variant
+	lru        *list.List               // front is the most recently used
+}
+
+var (
+	sharedStoreMu sync.RWMutex
+	sharedStore   *AnalysisStore
+)
+
+// NewAnalysisStore returns an empty store holding at most maxEntries analyses
+func NewAnalysisStore(maxEntries int) *AnalysisStore {
+	return &AnalysisStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		latest:     make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// SetSharedAnalysisStore sets the store the handlers use, nil disables storing
+func SetSharedAnalysisStore(store *AnalysisStore) {
+	sharedStoreMu.Lock()
+	defer sharedStoreMu.Unlock()
+	sharedStore = store
+}
+
+// SharedAnalysisStore returns the store the handlers use, nil when storing is disabled
+func SharedAnalysisStore() *AnalysisStore {
+	sharedStoreMu.RLock()
+	defer sharedStoreMu.RUnlock()
+	return sharedStore
+}
+
+// Save stores the analysis of url under a new random id
+func (s *AnalysisStore) Save(url string, data map[string]interface{}) *Analysis {
+	return s.SaveAnalysis(&Analysis{Url: url, Data: data})
+}
+
+// SaveAnalysis stores analysis under its id, a new random one when empty, it becomes the latest of its url and variant.
+// Data is published as it is, it must be complete and never modified afterwards
+func (s *AnalysisStore) SaveAnalysis(analysis *Analysis) *Analysis {
+	if analysis.ID == "" {
+		analysis.ID = NewID()
+	}
+	analysis.CreatedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem := s.lru.PushFront(analysis)
+	s.entries[analysis.ID] = elem
+	s.latest[latestKey(analysis.Url, analysis.Variant)] = elem
+	for s.lru.Len() > s.maxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		evicted := oldest.Value.(*Analysis)
+		delete(s.entries, evicted.ID)
+		if key := latestKey(evicted.Url, evicted.Variant); s.latest[key] == oldest {
+			delete(s.latest, key)
+		}
+	}
+	return analysis
+}
+
+// Latest returns the most recent analysis of url with the variant
+func (s *AnalysisStore) Latest(url string, variant string) (*Analysis, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.latest[latestKey(url, variant)]
+	if !ok {
+		return nil, false
+	}
+	s.lru.MoveToFront(elem)
+	return elem.Value.(*Analysis), true
+}
+
+// Get returns the stored analysis with id
+func (s *AnalysisStore) Get(id string) (*Analysis, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[id]
+	if !ok {
+		return nil, false
+	}
+	s.lru.MoveToFront(elem)
+	return elem.Value.(*Analysis), true
+}
+
+func latestKey(url string, variant string) string {
+	return url + "\n" + variant
+}
+
+// NewID returns a random analysis id, for results that carry their id before they are saved
+func NewID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic("Failed to generate analysis id: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
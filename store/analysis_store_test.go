@@ -0,0 +1,53 @@
+package store
+
+import "testing"
+
+func TestAnalysisStoreEviction(t *testing.T) {
+	s := NewAnalysisStore(2)
+	first := s.Save("https://a.example", map[string]interface{}{})
+	second := s.Save("https://b.example", map[string]interface{}{})
+	s.Get(first.ID)
+	s.Save("https://c.example", map[string]interface{}{})
+
+	if _, ok := s.Get(second.ID); ok {
+		t.Error("expected the least recently used analysis to be evicted")
+	}
+	if got, ok := s.Get(first.ID); !ok || got.Url != "https://a.example" {
+		t.Errorf("expected the first analysis to be kept, got %v %v", got, ok)
+	}
+	if first.ID == second.ID || len(first.ID) != 24 {
+		t.Errorf("expected unique 24 character ids, got %q and %q", first.ID, second.ID)
+	}
+}
+
+func TestAnalysisStoreLatest(t *testing.T) {
+	s := NewAnalysisStore(2)
+	s.SaveAnalysis(&Analysis{Url: "https://a.example", Variant: "v1", ETag: `"1"`})
+	second := s.SaveAnalysis(&Analysis{Url: "https://a.example", Variant: "v1", ETag: `"2"`})
+	s.SaveAnalysis(&Analysis{Url: "https://a.example", Variant: "v2"})
+
+	if latest, ok := s.Latest("https://a.example", "v1"); !ok || latest.ID != second.ID || latest.ETag != `"2"` {
+		t.Errorf("expected the newest analysis of the variant, got %v %v", latest, ok)
+	}
+	if _, ok := s.Latest("https://b.example", "v1"); ok {
+		t.Error("expected no analysis of another url")
+	}
+
+	// Evicting the latest analysis forgets it
+	s.Save("https://c.example", map[string]interface{}{})
+	s.Save("https://d.example", map[string]interface{}{})
+	if _, ok := s.Latest("https://a.example", "v1"); ok {
+		t.Error("expected the evicted analysis to be forgotten")
+	}
+}
+
+func TestAnalysisStoreKeepsGivenID(t *testing.T) {
+	s := NewAnalysisStore(2)
+	id := NewID()
+	data := map[string]interface{}{"analysisId": id}
+	s.SaveAnalysis(&Analysis{ID: id, Url: "https://a.example", Data: data})
+
+	if got, ok := s.Get(id); !ok || got.Data["analysisId"] != id {
+		t.Errorf("expected the analysis under the given id, got %v %v", got, ok)
+	}
+}